@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"os"
+
+	"alexdunmow.com/internal/config"
+	"alexdunmow.com/internal/skills"
+)
+
+// The static files and the skill tree are built into the binary so it runs
+// from any directory. In dev mode they are read from disk instead, so air's
+// rebuilds and edits to the CSS and JavaScript show up without embedding.
+
+//go:embed static/css static/js static/svg
+var embeddedStatic embed.FS
+
+//go:embed skills_tree.json
+var embeddedSkills []byte
+
+// staticFiles returns the files served under /static/.
+func staticFiles(cfg *config.Config) (fs.FS, error) {
+	if cfg.Dev {
+		return os.DirFS(cfg.StaticDir), nil
+	}
+	return fs.Sub(embeddedStatic, "static")
+}
+
+// loadSkills returns the skill tree.
+func loadSkills(cfg *config.Config) (*skills.Graph, error) {
+	if cfg.Dev {
+		return skills.LoadFile(cfg.SkillsFile)
+	}
+	return skills.Load(bytes.NewReader(embeddedSkills))
+}
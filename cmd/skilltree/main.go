@@ -1,148 +1,263 @@
+// Command skilltree queries and checks the skill graph in skills_tree.json.
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"os"
 	"strings"
 
-	"github.com/autom8ter/dagger"
+	"alexdunmow.com/internal/skills"
 )
 
-// Skill represents a node in our skills tree
-type Skill struct {
-	Name     string   `json:"name"`
-	Children []string `json:"children"`
-	Love     int      `json:"love"`
-	Icon     string   `json:"icon"`
+const usage = `usage: skilltree [--file path] <command> [arguments]
+
+commands:
+  tree <node>              print the skills under node as an indented tree
+  children <node>          print the direct children of node
+  parents <node>           print the skills that list node as a child
+  path <from> <to>         print the shortest path from one skill down to another
+  top [--by love] [-n 10]  print the highest rated skills
+  export [--format dot]    print the whole graph as Graphviz dot or Mermaid
+  validate                 check the data file and report every problem
+
+flags:
+  --file path              skill data file (default "skills_tree.json")
+`
+
+// errUsage marks errors caused by bad arguments, which exit with status 2.
+var errUsage = errors.New("usage")
+
+// errInvalid is returned by validate once it has reported the problems found.
+var errInvalid = errors.New("invalid skill data")
+
+// A command runs a subcommand. fs already has the --file flag defined; the
+// command adds its own flags before parsing args.
+type command func(file *string, fs *flag.FlagSet, args []string) error
+
+var commands = map[string]command{
+	"tree":     runTree,
+	"children": runChildren,
+	"parents":  runParents,
+	"path":     runPath,
+	"top":      runTop,
+	"export":   runExport,
+	"validate": runValidate,
 }
 
 func main() {
-	// Read JSON file
-	jsonFile, err := ioutil.ReadFile("skills_tree.json")
-	if err != nil {
-		log.Fatalf("Error reading JSON file: %v", err)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executes the command line in args and returns the process exit code.
+func run(args []string) int {
+	global := flag.NewFlagSet("skilltree", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	file := global.String("file", "skills_tree.json", "skill data file")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
 	}
 
-	// Parse JSON data
-	var skillsTree map[string]Skill
-	err = json.Unmarshal(jsonFile, &skillsTree)
-	if err != nil {
-		log.Fatalf("Error parsing JSON: %v", err)
+	if global.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	name := global.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "skilltree: unknown command %q\n\n%s", name, usage)
+		return 2
 	}
 
-	// Create a new graph
-	graph := dagger.NewGraph()
+	// Commands accept --file too, so it can go before or after the command name.
+	fs := flag.NewFlagSet("skilltree "+name, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	fs.StringVar(file, "file", *file, "skill data file")
 
-	// Add nodes and edges to the graph
-	for name, skill := range skillsTree {
-		// Add the skill as a node
-		err := graph.SetNode(dagger.Path{name}, skill)
-		if err != nil {
-			log.Fatalf("Error setting node: %v", err)
-		}
+	err := cmd(file, fs, global.Args()[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "skilltree %s: %v\n", name, err)
+		return 2
+	case errors.Is(err, errInvalid):
+		return 1
+	default:
+		fmt.Fprintf(os.Stderr, "skilltree %s: %v\n", name, err)
+		return 1
+	}
+}
 
-		// Add edges to children
-		for _, child := range skill.Children {
-			_, err = graph.SetEdge(dagger.Path{name}, dagger.Path{child}, "has_skill")
-			if err != nil {
-				log.Fatalf("Error setting edge: %v", err)
-			}
+// parse parses the command's flags, checks it was given exactly n positional
+// arguments and loads the graph.
+func parse(file *string, fs *flag.FlagSet, args []string, n int) (*skills.Graph, []string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, nil, err
 		}
+		return nil, nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() != n {
+		return nil, nil, fmt.Errorf("%w: expected %d argument(s), got %d", errUsage, n, fs.NArg())
+	}
+
+	graph, err := skills.LoadFile(*file)
+	if err != nil {
+		return nil, nil, err
 	}
+	return graph, fs.Args(), nil
+}
 
-	// Example: Retrieve and print the children of "Software Engineering"
-	printChildrenOf(graph, "Software Engineering")
+func runTree(file *string, fs *flag.FlagSet, args []string) error {
+	graph, args, err := parse(file, fs, args, 1)
+	if err != nil {
+		return err
+	}
+	return printTree(graph, args[0], 0, map[string]bool{})
+}
 
-	// Example: Find all skills that are children of "Technology Skills"
-	printChildrenOf(graph, "Technology Skills")
+func runChildren(file *string, fs *flag.FlagSet, args []string) error {
+	graph, args, err := parse(file, fs, args, 1)
+	if err != nil {
+		return err
+	}
+	children, err := graph.Children(args[0])
+	if err != nil {
+		return err
+	}
+	printSkills(children)
+	return nil
+}
 
-	// New example: Print the entire Programming Languages tree with love levels and icons
-	fmt.Println("\nProgramming Languages Tree (with love levels and icons):")
-	printProgrammingLanguagesTree(graph, "Programming Languages", 0)
+func runParents(file *string, fs *flag.FlagSet, args []string) error {
+	graph, args, err := parse(file, fs, args, 1)
+	if err != nil {
+		return err
+	}
+	parents, err := graph.Parents(args[0])
+	if err != nil {
+		return err
+	}
+	printSkills(parents)
+	return nil
+}
 
-	// Print skills sorted by love level
-	printSkillsSortedByLove(graph)
+func runPath(file *string, fs *flag.FlagSet, args []string) error {
+	graph, args, err := parse(file, fs, args, 2)
+	if err != nil {
+		return err
+	}
+	path, err := graph.Path(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	for depth, skill := range path {
+		fmt.Printf("%s%s %s (Love: %d)\n", getIndentation(depth), skill.Icon, skill.Name, skill.Love)
+	}
+	return nil
 }
 
-func printChildrenOf(graph *dagger.Graph, nodeName string) {
-	node, err := graph.GetNode(dagger.Path{nodeName})
+func runTop(file *string, fs *flag.FlagSet, args []string) error {
+	by := fs.String("by", "love", "what to rank skills by (only love is supported)")
+	n := fs.Int("n", 10, "number of skills to print, or 0 for all")
+	graph, _, err := parse(file, fs, args, 0)
 	if err != nil {
-		log.Fatalf("Error getting node: %v", err)
+		return err
+	}
+	if *by != "love" {
+		return fmt.Errorf("%w: cannot rank by %q, only by love", errUsage, *by)
+	}
+	if *n < 0 {
+		return fmt.Errorf("%w: -n must not be negative", errUsage)
 	}
 
-	skill, ok := node.(Skill)
-	if !ok {
-		log.Fatalf("Node is not a Skill")
+	sorted := graph.SortedByLove()
+	if *n > 0 && *n < len(sorted) {
+		sorted = sorted[:*n]
 	}
+	printSkills(sorted)
+	return nil
+}
 
-	fmt.Printf("\nChildren of %s %s (Love: %d):\n", skill.Icon, nodeName, skill.Love)
-	for _, childName := range skill.Children {
-		childNode, _ := graph.GetNode(dagger.Path{childName})
-		childSkill, _ := childNode.(Skill)
-		fmt.Printf("- %s %s (Love: %d)\n", childSkill.Icon, childName, childSkill.Love)
+func runExport(file *string, fs *flag.FlagSet, args []string) error {
+	name := fs.String("format", string(skills.FormatDOT), "diagram format: dot or mermaid")
+	graph, _, err := parse(file, fs, args, 0)
+	if err != nil {
+		return err
 	}
+	format, err := skills.ParseFormat(*name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return graph.Export(os.Stdout, format)
 }
 
-func printProgrammingLanguagesTree(graph *dagger.Graph, nodeName string, depth int) {
-	node, err := graph.GetNode(dagger.Path{nodeName})
+func runValidate(file *string, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: validate takes no arguments, use --file to choose the file", errUsage)
+	}
+
+	data, err := os.ReadFile(*file)
 	if err != nil {
-		log.Fatalf("Error getting node: %v", err)
+		return err
 	}
 
-	skill, ok := node.(Skill)
-	if !ok {
-		log.Fatalf("Node is not a Skill")
+	problems := skills.Validate(data)
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "%s:%d: %s\n", *file, p.Line, p)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) found in %s\n", len(problems), *file)
+		return errInvalid
+	}
+
+	fmt.Printf("%s: ok\n", *file)
+	return nil
+}
+
+// printTree prints the skill and everything under it. Skills with several
+// parents are printed under each of them. onPath holds the skills above this
+// one, so cyclic data is reported instead of recursing forever.
+func printTree(graph *skills.Graph, nodeName string, depth int, onPath map[string]bool) error {
+	skill, err := graph.Get(nodeName)
+	if err != nil {
+		return err
+	}
+	if onPath[nodeName] {
+		return fmt.Errorf("cycle through %q, run skilltree validate", nodeName)
 	}
 
 	// Print the current node with love level and icon
 	fmt.Printf("%s%s %s (Love: %d)\n", getIndentation(depth), skill.Icon, skill.Name, skill.Love)
 
-	// Get and print children
+	onPath[nodeName] = true
+	defer delete(onPath, nodeName)
+
 	for _, childName := range skill.Children {
-		childEdges, err := graph.GetEdges(dagger.Path{childName}, "has_skill")
-		if err != nil {
-			log.Fatalf("Error getting edges: %v", err)
+		if err := printTree(graph, childName, depth+1, onPath); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		if len(childEdges) == 0 {
-			// This is a leaf node (actual programming language)
-			childNode, _ := graph.GetNode(dagger.Path{childName})
-			childSkill, _ := childNode.(Skill)
-			fmt.Printf("%s- %s %s (Love: %d)\n", getIndentation(depth+1), childSkill.Icon, childName, childSkill.Love)
-		} else {
-			// This is an intermediate node, recurse
-			printProgrammingLanguagesTree(graph, childName, depth+1)
-		}
+func printSkills(list []skills.Skill) {
+	for _, skill := range list {
+		fmt.Printf("%s %s (Love: %d)\n", skill.Icon, skill.Name, skill.Love)
 	}
 }
 
 func getIndentation(depth int) string {
 	return strings.Repeat("  ", depth)
 }
-
-func printSkillsSortedByLove(graph *dagger.Graph) {
-	var skills []Skill
-	graph.ForEachNode(func(path dagger.Path, value interface{}) bool {
-		skill, ok := value.(Skill)
-		if ok {
-			skills = append(skills, skill)
-		}
-		return true
-	})
-
-	// Sort skills by love level (descending)
-	for i := 0; i < len(skills); i++ {
-		for j := i + 1; j < len(skills); j++ {
-			if skills[i].Love < skills[j].Love {
-				skills[i], skills[j] = skills[j], skills[i]
-			}
-		}
-	}
-
-	fmt.Println("\nSkills sorted by love level (descending):")
-	for _, skill := range skills {
-		fmt.Printf("%s %s (Love: %d)\n", skill.Icon, skill.Name, skill.Love)
-	}
-}
@@ -3,7 +3,8 @@ module alexdunmow.com
 go 1.23.1
 
 require (
-	github.com/autom8ter/dagger v1.0.1
+	github.com/andybalholm/brotli v1.1.1
 	github.com/joho/godotenv v1.5.1
 	github.com/maragudk/gomponents v0.21.0
+	golang.org/x/crypto v0.28.0
 )
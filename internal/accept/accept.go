@@ -0,0 +1,65 @@
+// Package accept negotiates response encodings with clients.
+package accept
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Encoding returns the first of offered that the request's Accept-Encoding
+// header ranks highest, or "" if it accepts none of them.
+func Encoding(r *http.Request, offered ...string) string {
+	best, bestQ := "", 0.0
+	for _, enc := range offered {
+		if q := quality(r.Header.Values("Accept-Encoding"), enc); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// quality returns the weight the Accept-Encoding values give enc: its own
+// entry if listed, else the "*" entry, else 0.
+func quality(header []string, enc string) float64 {
+	q, wildcard := -1.0, -1.0
+	for _, line := range header {
+		for _, part := range strings.Split(line, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.TrimSpace(name)
+			value := 1.0
+			if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+				f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil {
+					continue
+				}
+				value = f
+			}
+			switch {
+			case strings.EqualFold(name, enc):
+				q = value
+			case name == "*":
+				wildcard = value
+			}
+		}
+	}
+	switch {
+	case q >= 0:
+		return q
+	case wildcard >= 0:
+		return wildcard
+	}
+	return 0
+}
+
+// Vary adds field to h's Vary header unless it is already listed.
+func Vary(h http.Header, field string) {
+	for _, line := range h.Values("Vary") {
+		for _, f := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
@@ -0,0 +1,51 @@
+package accept
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		want   string
+	}{
+		{"no header", nil, ""},
+		{"gzip only", []string{"gzip"}, "gzip"},
+		{"both, offered order breaks the tie", []string{"gzip, br"}, "br"},
+		{"ranked by q", []string{"br;q=0.5, gzip;q=0.8"}, "gzip"},
+		{"refused", []string{"br;q=0, gzip"}, "gzip"},
+		{"all refused", []string{"br;q=0, gzip;q=0"}, ""},
+		{"wildcard", []string{"*"}, "br"},
+		{"wildcard with an exception", []string{"*;q=0.5, br;q=0"}, "gzip"},
+		{"case and spaces", []string{" GZIP ; q = 0.9 "}, "gzip"},
+		{"several lines", []string{"identity", "gzip"}, "gzip"},
+		{"bad q is skipped", []string{"br;q=high, gzip;q=0.1"}, "gzip"},
+		{"unknown only", []string{"deflate, identity"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, v := range tt.header {
+				r.Header.Add("Accept-Encoding", v)
+			}
+			if got := Encoding(r, "br", "gzip"); got != tt.want {
+				t.Errorf("Encoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVary(t *testing.T) {
+	h := http.Header{}
+	h.Add("Vary", "Accept-Encoding, HX-Request")
+	Vary(h, "hx-request")
+	Vary(h, "HX-Boosted")
+	Vary(h, "HX-Boosted")
+	got := h.Values("Vary")
+	if len(got) != 2 || got[1] != "HX-Boosted" {
+		t.Errorf("Vary = %q, want HX-Boosted added once", got)
+	}
+}
@@ -0,0 +1,236 @@
+// Package assets fingerprints static files so they can be cached forever.
+//
+// A Manifest hashes every file once at startup. Path turns a file name such as
+// "js/bundle.js" into a URL like "/static/js/bundle.1a2b3c4d5e.js", which
+// changes whenever the file does, and Serve answers both forms: hashed URLs
+// are cached as immutable, plain ones are revalidated with an ETag.
+package assets
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"alexdunmow.com/internal/accept"
+)
+
+// Prefix is the URL path static files are served under.
+const Prefix = "/static/"
+
+// hashLen is how many hex digits of the SHA-256 go into a fingerprint.
+const hashLen = 10
+
+// Manifest maps static file names to their content-hashed names.
+type Manifest struct {
+	fsys fs.FS
+	// hashes maps a file name to its content hash. It is nil when the
+	// manifest doesn't fingerprint, so files are hashed on every request.
+	hashes map[string]string
+	// names maps a hashed name back to the file name.
+	names map[string]string
+	// integrity maps a file name to its subresource integrity value.
+	integrity map[string]string
+}
+
+// New returns a manifest for the files in fsys. With fingerprint false, as in
+// dev mode where files change under the running server, Path returns plain
+// URLs and nothing is cached between requests.
+func New(fsys fs.FS, fingerprint bool) (*Manifest, error) {
+	m := &Manifest{fsys: fsys}
+	if !fingerprint {
+		return m, nil
+	}
+
+	m.hashes = map[string]string{}
+	m.names = map[string]string{}
+	m.integrity = map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		sum, err := hashFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		m.hashes[name] = sum
+		m.names[hashedName(name, sum)] = name
+		if m.integrity[name], err = integrity(fsys, name); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building asset manifest: %w", err)
+	}
+	return m, nil
+}
+
+// FS returns the files the manifest describes.
+func (m *Manifest) FS() fs.FS {
+	return m.fsys
+}
+
+// Path returns the URL of the named file, fingerprinted if the manifest
+// knows it.
+func (m *Manifest) Path(name string) string {
+	if sum, ok := m.hashes[name]; ok {
+		return Prefix + hashedName(name, sum)
+	}
+	return Prefix + name
+}
+
+// Exists reports whether the named file is in the manifest's files.
+func (m *Manifest) Exists(name string) bool {
+	if _, ok := m.hashes[name]; ok {
+		return true
+	}
+	info, err := fs.Stat(m.fsys, name)
+	return err == nil && !info.IsDir()
+}
+
+// Integrity returns the subresource integrity value of the named file, such
+// as "sha384-...", or "" if it can't be read.
+func (m *Manifest) Integrity(name string) string {
+	if v, ok := m.integrity[name]; ok {
+		return v
+	}
+	v, _ := integrity(m.fsys, name)
+	return v
+}
+
+// Serve writes the file at name, which may be a plain or hashed name. If
+// nothing can be served it writes nothing and returns the error, which wraps
+// fs.ErrNotExist when there is no such file.
+func (m *Manifest) Serve(w http.ResponseWriter, r *http.Request, name string) error {
+	if orig, ok := m.names[name]; ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		m.serve(w, r, orig, m.hashes[orig])
+		return nil
+	}
+
+	sum, ok := m.hashes[name]
+	if !ok {
+		var err error
+		sum, err = hashFile(m.fsys, name)
+		if errors.Is(err, errDir) {
+			return fmt.Errorf("%s %w: %w", name, errDir, fs.ErrNotExist)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	m.serve(w, r, name, sum)
+	return nil
+}
+
+// encodings are the precompressed siblings Serve looks for, best first.
+var encodings = []struct{ name, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// serve writes name, or a precompressed sibling such as name.br when the
+// client accepts it, with sum as the ETag.
+func (m *Manifest) serve(w http.ResponseWriter, r *http.Request, name, sum string) {
+	accept.Vary(w.Header(), "Accept-Encoding")
+
+	var offered []string
+	for _, e := range encodings {
+		if _, err := fs.Stat(m.fsys, name+e.ext); err == nil {
+			offered = append(offered, e.name)
+		}
+	}
+	file, etag := name, sum
+	if enc := accept.Encoding(r, offered...); enc != "" {
+		for _, e := range encodings {
+			if e.name == enc {
+				file, etag = name+e.ext, sum+"-"+e.name
+			}
+		}
+		w.Header().Set("Content-Encoding", enc)
+		if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+			w.Header().Set("Content-Type", ctype)
+		}
+	}
+
+	w.Header().Set("ETag", `"`+etag+`"`)
+	// ServeFileFS answers If-None-Match against the ETag with a 304.
+	http.ServeFileFS(w, r, m.fsys, file)
+}
+
+var errDir = errors.New("is a directory")
+
+func hashFile(fsys fs.FS, name string) (string, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", errDir
+	}
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:hashLen], nil
+}
+
+func integrity(fsys fs.FS, name string) (string, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha512.Sum384(b)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// hashedName inserts sum before the extension: css/theme.css becomes
+// css/theme.<sum>.css.
+func hashedName(name, sum string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + sum + ext
+}
+
+var defaultManifest atomic.Pointer[Manifest]
+
+// SetDefault makes m the manifest used by the package level Path.
+func SetDefault(m *Manifest) {
+	defaultManifest.Store(m)
+}
+
+// Path returns the URL of the named file using the default manifest, or the
+// plain URL if none has been set.
+func Path(name string) string {
+	if m := defaultManifest.Load(); m != nil {
+		return m.Path(name)
+	}
+	return Prefix + name
+}
+
+// Exists reports whether the default manifest has the named file.
+func Exists(name string) bool {
+	if m := defaultManifest.Load(); m != nil {
+		return m.Exists(name)
+	}
+	return false
+}
+
+// Integrity returns the named file's integrity value from the default
+// manifest, or "" if none has been set.
+func Integrity(name string) string {
+	if m := defaultManifest.Load(); m != nil {
+		return m.Integrity(name)
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+// Package auth provides users, password hashing and signed session cookies.
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials is returned when an email and password don't match a user.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrUserNotFound is returned by a UserStore when no user matches.
+var ErrUserNotFound = errors.New("user not found")
+
+// User is an account that can sign in.
+type User struct {
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"passwordHash"`
+}
+
+// DisplayName returns the name shown to other visitors: the part of the
+// email before the @, so the address itself stays private.
+func (u *User) DisplayName() string {
+	name, _, _ := strings.Cut(u.Email, "@")
+	return name
+}
+
+// UserStore looks up and creates users.
+type UserStore interface {
+	ByID(id string) (*User, error)
+	ByEmail(email string) (*User, error)
+	Create(email, password string) (*User, error)
+}
+
+// dummyHash is compared against when a login names an unknown email, so that
+// response times don't reveal which addresses have accounts.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// HashPassword returns a bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Authenticate returns the user with the given email if password matches.
+func Authenticate(users UserStore, email, password string) (*User, error) {
+	user, err := users.ByEmail(NormalizeEmail(email))
+	if errors.Is(err, ErrUserNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// NormalizeEmail returns the form of email used as a lookup key.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
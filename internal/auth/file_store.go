@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"alexdunmow.com/internal/jsonfile"
+)
+
+// FileStore keeps users in memory and persists them to a single JSON file.
+type FileStore struct {
+	path string
+
+	mu    sync.RWMutex
+	users []*User
+}
+
+// NewFileStore opens the user store at path. A missing file is treated as an
+// empty store.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+
+	if err := jsonfile.Load(path, &s.users); err != nil {
+		return nil, fmt.Errorf("loading users: %w", err)
+	}
+	return s, nil
+}
+
+// ByID returns the user with the given ID.
+func (s *FileStore) ByID(id string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// ByEmail returns the user with the given normalized email.
+func (s *FileStore) ByEmail(email string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// Create adds a user with a hashed password and writes the store to disk.
+func (s *FileStore) Create(email, password string) (*User, error) {
+	email = NormalizeEmail(email)
+	if email == "" || password == "" {
+		return nil, errors.New("email and password are required")
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("hashing password: %w", err)
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("generating user id: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return nil, fmt.Errorf("user %s already exists", email)
+		}
+	}
+
+	user := &User{ID: id, Email: email, PasswordHash: hash}
+	s.users = append(s.users, user)
+	if err := jsonfile.Save(s.path, s.users); err != nil {
+		s.users = s.users[:len(s.users)-1]
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/layout"
+	g "github.com/maragudk/gomponents"
+)
+
+// Handler serves the login and logout endpoints.
+type Handler struct {
+	Users    UserStore
+	Sessions *Sessions
+}
+
+// ShowLogin renders the login page, or sends signed-in users on their way.
+func (h *Handler) ShowLogin(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	next := SafeNext(r.URL.Query().Get("next"))
+	if id, ok := h.Sessions.UserID(r); ok {
+		if _, err := h.Users.ByID(id); err == nil {
+			Redirect(w, r, next)
+			return nil, nil
+		}
+		// The account behind a still-valid cookie is gone. Sending the
+		// client on to next would only bring it back here, so drop the
+		// session and show the form.
+		h.Sessions.Clear(w, r)
+	}
+
+	return layout.Render(w, r, loginPage("", next, "")), nil
+}
+
+// Login checks the posted credentials and starts a session.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	email := strings.TrimSpace(r.FormValue("email"))
+	next := SafeNext(r.FormValue("next"))
+
+	user, err := Authenticate(h.Users, email, r.FormValue("password"))
+	if errors.Is(err, ErrInvalidCredentials) {
+		msg := "Invalid email or password."
+		if r.Header.Get("HX-Request") == "true" {
+			return components.LoginForm(email, next, msg), nil
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		return layout.Render(w, r, loginPage(email, next, msg)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.Sessions.Issue(w, r, user.ID)
+	Redirect(w, r, next)
+	return nil, nil
+}
+
+func loginPage(email, next, errMsg string) layout.Page {
+	return layout.Page{Title: "Log in", Active: "login", Body: components.Login(email, next, errMsg)}
+}
+
+// Logout ends the session and returns to the login page.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	h.Sessions.Clear(w, r)
+	Redirect(w, r, "/login")
+	return nil, nil
+}
+
+// Redirect sends the client to path, using HX-Redirect for HTMX requests so
+// the whole page is replaced rather than swapped into the request's target.
+func Redirect(w http.ResponseWriter, r *http.Request, path string) {
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", path)
+		return
+	}
+	http.Redirect(w, r, path, http.StatusSeeOther)
+}
+
+// LoginURL returns the login page URL that returns to r's path afterwards.
+func LoginURL(r *http.Request) string {
+	next := r.URL.RequestURI()
+	if r.Method != http.MethodGet {
+		// Returning to a POST endpoint after login would 405, so go back to
+		// the page that submitted it instead.
+		next = refererPath(r)
+	}
+	return "/login?next=" + url.QueryEscape(next)
+}
+
+// SafeNext returns next if it is a local path, or "/dashboard" otherwise, so
+// the login form can't be used as an open redirect. Browsers ignore tabs and
+// newlines in URLs and treat backslashes as slashes, so any of those rejects
+// next outright: "/\t/evil.com" would otherwise be followed as "//evil.com".
+func SafeNext(next string) string {
+	const fallback = "/dashboard"
+	if !strings.HasPrefix(next, "/") || strings.ContainsFunc(next, unsafeInURL) {
+		return fallback
+	}
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(next, "//") {
+		return fallback
+	}
+	return next
+}
+
+func unsafeInURL(c rune) bool {
+	return c < ' ' || c == 0x7f || c == '\\'
+}
+
+func refererPath(r *http.Request) string {
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Host != r.Host {
+		return "/dashboard"
+	}
+	return SafeNext(u.RequestURI())
+}
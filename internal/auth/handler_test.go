@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSafeNext(t *testing.T) {
+	tests := []struct {
+		next, want string
+	}{
+		{"/settings", "/settings"},
+		{"/skills/go?tab=tree#top", "/skills/go?tab=tree#top"},
+		{"/", "/"},
+		{"", "/dashboard"},
+		{"dashboard", "/dashboard"},
+		{"https://evil.com", "/dashboard"},
+		{"//evil.com", "/dashboard"},
+		{"/\\evil.com", "/dashboard"},
+		{"/a\\b", "/dashboard"},
+		{"/\t/evil.com", "/dashboard"},
+		{"/\n/evil.com", "/dashboard"},
+		{"/\r\n/evil.com", "/dashboard"},
+		{"/\x00/evil.com", "/dashboard"},
+		{"/\x7f", "/dashboard"},
+		{"javascript:alert(1)", "/dashboard"},
+		{"/%09/evil.com", "/%09/evil.com"},
+	}
+	for _, tt := range tests {
+		if got := SafeNext(tt.next); got != tt.want {
+			t.Errorf("SafeNext(%q) = %q, want %q", tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestShowLogin(t *testing.T) {
+	users, err := NewFileStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := users.Create("a@example.com", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{Users: users, Sessions: testSessions()}
+
+	tests := []struct {
+		name   string
+		userID string
+		// redirect is the expected Location, or "" if the form is shown.
+		redirect string
+	}{
+		{"anonymous", "", ""},
+		{"signed in", user.ID, "/settings"},
+		{"account gone", "deleted", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/login?next=/settings", nil)
+			if tt.userID != "" {
+				r.AddCookie(sessionCookie(t, h.Sessions, tt.userID))
+			}
+			w := httptest.NewRecorder()
+			node, err := h.ShowLogin(w, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Header().Get("Location"); got != tt.redirect {
+				t.Errorf("Location = %q, want %q", got, tt.redirect)
+			}
+			if showsForm := node != nil; showsForm != (tt.redirect == "") {
+				t.Errorf("ShowLogin() returned a page: %v, want %v", showsForm, tt.redirect == "")
+			}
+			if tt.name == "account gone" {
+				c := w.Result().Cookies()
+				if len(c) != 1 || c[0].Name != SessionCookie || c[0].MaxAge >= 0 {
+					t.Errorf("cookies = %v, want the session cleared", c)
+				}
+			}
+		})
+	}
+}
+
+func testSessions() *Sessions {
+	return &Sessions{Secret: []byte("0123456789abcdef0123456789abcdef"), TTL: time.Hour}
+}
+
+// sessionCookie returns the cookie s issues for userID.
+func sessionCookie(t *testing.T, s *Sessions, userID string) *http.Cookie {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.Issue(w, httptest.NewRequest(http.MethodGet, "/", nil), userID)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Issue set %d cookies, want 1", len(cookies))
+	}
+	return cookies[0]
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionCookie is the name of the cookie holding the signed session.
+const SessionCookie = "session"
+
+// Sessions issues and verifies session cookies signed with HMAC-SHA256.
+// The cookie value is "<user id>|<expiry unix>|<signature>", so no server-side
+// session state is needed.
+type Sessions struct {
+	Secret []byte
+	TTL    time.Duration
+}
+
+// Issue sets a session cookie for userID on w.
+func (s *Sessions) Issue(w http.ResponseWriter, r *http.Request, userID string) {
+	expires := time.Now().Add(s.TTL)
+	payload := userID + "|" + strconv.FormatInt(expires.Unix(), 10)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    payload + "|" + s.sign(payload),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// UserID returns the user ID from a valid, unexpired session cookie on r.
+func (s *Sessions) UserID(r *http.Request) (string, bool) {
+	c, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return "", false
+	}
+
+	i := strings.LastIndexByte(c.Value, '|')
+	if i < 0 {
+		return "", false
+	}
+	payload, sig := c.Value[:i], c.Value[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return "", false
+	}
+
+	userID, expiry, ok := strings.Cut(payload, "|")
+	if !ok || userID == "" {
+		return "", false
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return "", false
+	}
+	return userID, true
+}
+
+// Clear removes the session cookie.
+func (s *Sessions) Clear(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (s *Sessions) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	s := testSessions()
+	valid := sessionCookie(t, s, "u1").Value
+	payload := valid[:strings.LastIndexByte(valid, '|')]
+	expired := "u1|" + strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	other := &Sessions{Secret: []byte("another secret, also 32 bytes.."), TTL: time.Hour}
+
+	tests := []struct {
+		name   string
+		value  string
+		wantID string
+		wantOK bool
+	}{
+		{"valid", valid, "u1", true},
+		{"no cookie", "", "", false},
+		{"tampered user", "u2" + valid[2:], "", false},
+		{"tampered signature", payload + "|" + s.sign(payload+"x"), "", false},
+		{"other secret", payload + "|" + other.sign(payload), "", false},
+		{"expired", expired + "|" + s.sign(expired), "", false},
+		{"unsigned", payload, "", false},
+		{"empty user", "|1|" + s.sign("|1"), "", false},
+		{"bad expiry", "u1|soon|" + s.sign("u1|soon"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.value != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.value})
+			}
+			id, ok := s.UserID(r)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("UserID() = %q, %v, want %q, %v", id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSessionsIssue(t *testing.T) {
+	s := testSessions()
+	c := sessionCookie(t, s, "u1")
+	if !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/" {
+		t.Errorf("cookie = %+v, want HttpOnly, SameSite=Lax and Path=/", c)
+	}
+	if until := time.Until(c.Expires); until <= 59*time.Minute || until > time.Hour {
+		t.Errorf("cookie expires in %v, want the TTL of %v", until, s.TTL)
+	}
+}
@@ -0,0 +1,225 @@
+// Package chat stores recent chat messages and broadcasts new ones to every
+// connected client.
+package chat
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest message, in characters, that Post accepts.
+const MaxLength = 500
+
+// ErrEmpty is returned by Post for messages with no text.
+var ErrEmpty = errors.New("message is empty")
+
+// ErrTooLong is returned by Post for messages longer than MaxLength.
+var ErrTooLong = fmt.Errorf("message is longer than %d characters", MaxLength)
+
+// Message is a single chat message.
+type Message struct {
+	ID     uint64    `json:"id"`
+	UserID string    `json:"userId,omitempty"`
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+	Time   time.Time `json:"time"`
+}
+
+// Hub keeps the most recent messages in a ring buffer, optionally appends
+// every message to a log file, and fans new messages out to subscribers.
+type Hub struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history *ring
+	subs    map[chan Message]struct{}
+	log     *os.File
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewHub returns a hub remembering the last capacity messages. If logPath is
+// not empty, messages are appended to it as JSON lines and the most recent
+// ones are restored from it on startup.
+func NewHub(capacity int, logPath string) (*Hub, error) {
+	h := &Hub{
+		nextID:  1,
+		history: newRing(capacity),
+		subs:    map[chan Message]struct{}{},
+		done:    make(chan struct{}),
+	}
+	if logPath == "" {
+		return h, nil
+	}
+
+	if err := h.restore(logPath); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return nil, fmt.Errorf("creating chat log directory: %w", err)
+	}
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening chat log: %w", err)
+	}
+	h.log = f
+	return h, nil
+}
+
+// Post validates and stores a message, then delivers it to every subscriber.
+func (h *Hub) Post(userID, author, text string) (Message, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Message{}, ErrEmpty
+	}
+	if utf8.RuneCountInString(text) > MaxLength {
+		return Message{}, ErrTooLong
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m := Message{
+		ID:     h.nextID,
+		UserID: userID,
+		Author: author,
+		Text:   text,
+		Time:   time.Now().UTC(),
+	}
+
+	if h.log != nil {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return Message{}, fmt.Errorf("encoding chat message: %w", err)
+		}
+		if _, err := h.log.Write(append(b, '\n')); err != nil {
+			return Message{}, fmt.Errorf("writing chat log: %w", err)
+		}
+	}
+
+	h.nextID++
+	h.history.add(m)
+
+	for sub := range h.subs {
+		select {
+		case sub <- m:
+		default:
+			// The subscriber isn't keeping up; it misses this message rather
+			// than stalling everyone else.
+		}
+	}
+	return m, nil
+}
+
+// History returns the remembered messages, oldest first.
+func (h *Hub) History() []Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.history.messages()
+}
+
+// Subscribe returns a channel receiving every message posted from now on, and
+// a function that must be called to stop receiving them.
+func (h *Hub) Subscribe() (<-chan Message, func()) {
+	sub := make(chan Message, 16)
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub, func() {
+		h.mu.Lock()
+		delete(h.subs, sub)
+		h.mu.Unlock()
+	}
+}
+
+// Done returns a channel that is closed when the hub is closed, telling
+// subscribers to stop.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Close closes the chat log, if any, and closes Done. It is safe to call more
+// than once.
+func (h *Hub) Close() error {
+	h.closeOnce.Do(func() { close(h.done) })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.log == nil {
+		return nil
+	}
+	err := h.log.Close()
+	h.log = nil
+	return err
+}
+
+// restore loads the tail of the log at path into the history.
+func (h *Hub) restore(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening chat log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var m Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return fmt.Errorf("parsing chat log %s line %d: %w", path, line, err)
+		}
+		h.history.add(m)
+		if m.ID >= h.nextID {
+			h.nextID = m.ID + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading chat log: %w", err)
+	}
+	return nil
+}
+
+// ring is a fixed-size buffer that overwrites its oldest message when full.
+type ring struct {
+	buf   []Message
+	start int
+	size  int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{buf: make([]Message, max(capacity, 1))}
+}
+
+func (r *ring) add(m Message) {
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = m
+		r.size++
+		return
+	}
+	r.buf[r.start] = m
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+func (r *ring) messages() []Message {
+	out := make([]Message, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		out = append(out, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/middleware"
+	g "github.com/maragudk/gomponents"
+)
+
+// keepAlive is how often an idle event stream sends a comment, so proxies
+// don't time it out and disconnected clients are noticed.
+const keepAlive = 25 * time.Second
+
+// Handler serves the chat endpoints.
+type Handler struct {
+	Hub *Hub
+}
+
+// Send posts the submitted message. The sender sees it arrive over the event
+// stream like everyone else, so HTMX requests get an emptied chat form back,
+// or the form with the message and an error if it was rejected.
+func (h *Handler) Send(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	userID, author := "", "Guest"
+	if user := middleware.CurrentUser(r); user != nil {
+		userID, author = user.ID, user.DisplayName()
+	}
+	htmx := r.Header.Get("HX-Request") == "true"
+
+	text := r.FormValue("message")
+	_, err := h.Hub.Post(userID, author, text)
+	if errors.Is(err, ErrEmpty) || errors.Is(err, ErrTooLong) {
+		if htmx {
+			// htmx does not swap 4xx responses by default, so the form is
+			// re-rendered with a 200 and the error inline.
+			return components.ChatForm(text, errorMessage(err)), nil
+		}
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if htmx {
+		return components.ChatForm("", ""), nil
+	}
+	back := r.Referer()
+	if back == "" {
+		back = "/"
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+	return nil, nil
+}
+
+// errorMessage returns the message shown for a rejected chat message.
+func errorMessage(err error) string {
+	if errors.Is(err, ErrTooLong) {
+		return fmt.Sprintf("Messages can be at most %d characters.", MaxLength)
+	}
+	return "Type a message first."
+}
+
+// Events streams the remembered messages, then new ones as they are posted,
+// as Server-Sent Events. Each event's data is the rendered message HTML, ready
+// to append to #chat-messages. A reconnecting client's Last-Event-ID skips
+// the messages it already has.
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	messages, unsubscribe := h.Hub.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	// Subscribing came first, so a message posted since then is both in the
+	// history and on its way; last makes sure each is sent once.
+	viewer := viewerID(r)
+	last, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	send := func(m Message) error {
+		if m.ID <= last {
+			return nil
+		}
+		last = m.ID
+		var b strings.Builder
+		if err := render(m, viewer).Render(&b); err != nil {
+			return err
+		}
+		return writeEvent(w, m.ID, "message", b.String())
+	}
+	for _, m := range h.Hub.History() {
+		if err := send(m); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.Hub.Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case m := <-messages:
+			if err := send(m); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes a single Server-Sent Event, splitting data over as many
+// data lines as it has lines.
+func writeEvent(w http.ResponseWriter, id uint64, event, data string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "id: %d\nevent: %s\n", id, event)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	_, err := fmt.Fprint(w, b.String())
+	return err
+}
+
+func render(m Message, viewer string) g.Node {
+	return components.ChatMessage(m.Author, m.Text, viewer != "" && m.UserID == viewer)
+}
+
+func viewerID(r *http.Request) string {
+	if user := middleware.CurrentUser(r); user != nil {
+		return user.ID
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package components
 
 import (
+	"alexdunmow.com/internal/assets"
 	g "github.com/maragudk/gomponents"
 	. "github.com/maragudk/gomponents/html"
 )
@@ -10,7 +11,7 @@ func Banner() g.Node {
 	return Header(
 		Class("p-6 flex flex-col justify-between bg-primary text-text"),
 		Img(
-			Src("/static/svg/logo.svg"),
+			Src(assets.Path("svg/logo.svg")),
 			Class("h-20 w-20"),
 			Alt("Logo"),
 		),
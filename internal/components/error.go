@@ -0,0 +1,36 @@
+package components
+
+import (
+	"net/http"
+
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+// Error renders the content of an error page for an HTTP status code. The
+// request ID lets visitors quote the failing request when reporting it.
+func Error(status int, requestID string) g.Node {
+	message := "The request couldn't be completed."
+	switch {
+	case status == http.StatusNotFound:
+		message = "We couldn't find the page you were looking for."
+	case status >= 500:
+		message = "Something went wrong on our side. Please try again in a moment."
+	}
+
+	return Div(
+		ID("error-page"),
+		Class("space-y-4"),
+		H1(Class("text-3xl font-bold text-text"), g.Textf("%d %s", status, http.StatusText(status))),
+		P(Class("text-text"), g.Text(message)),
+		g.If(requestID != "",
+			P(
+				Class("text-sm text-text"),
+				g.Text("If the problem persists, please quote request ID "),
+				Code(Class("font-mono"), g.Text(requestID)),
+				g.Text("."),
+			),
+		),
+		ContentLink("/", Class("text-accent hover:underline"), g.Text("Back to the home page")),
+	)
+}
@@ -0,0 +1,73 @@
+package components
+
+import (
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+// Login renders the login page content.
+func Login(email, next, errMsg string) g.Node {
+	return Div(
+		Class("space-y-6 max-w-md"),
+		H1(Class("text-3xl font-bold text-text"), g.Text("Log in")),
+		LoginForm(email, next, errMsg),
+	)
+}
+
+// LoginForm renders the login form. next is the path to return to after a
+// successful login.
+func LoginForm(email, next, errMsg string) g.Node {
+	return Form(
+		ID("login-form"),
+		Method("post"),
+		Action("/login"),
+		Data("hx-post", "/login"),
+		Data("hx-target", "this"),
+		Data("hx-swap", "outerHTML"),
+		Class("space-y-4"),
+		Input(Type("hidden"), Name("next"), Value(next)),
+		g.If(errMsg != "",
+			P(
+				Role("alert"),
+				Class("px-3 py-2 bg-secondary text-red-500 rounded-md"),
+				g.Text(errMsg),
+			),
+		),
+		Div(
+			Label(
+				For("login-email"),
+				Class("block text-sm font-medium text-text mb-1"),
+				g.Text("Email"),
+			),
+			Input(
+				Type("email"),
+				ID("login-email"),
+				Name("email"),
+				Value(email),
+				AutoComplete("username"),
+				Required(),
+				Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
+			),
+		),
+		Div(
+			Label(
+				For("login-password"),
+				Class("block text-sm font-medium text-text mb-1"),
+				g.Text("Password"),
+			),
+			Input(
+				Type("password"),
+				ID("login-password"),
+				Name("password"),
+				AutoComplete("current-password"),
+				Required(),
+				Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
+			),
+		),
+		Button(
+			Type("submit"),
+			Class("px-4 py-2 bg-accent text-primary rounded-md hover:bg-opacity-80 transition-colors duration-200"),
+			g.Text("Log in"),
+		),
+	)
+}
@@ -1,90 +1,126 @@
 package components
 
 import (
+	"alexdunmow.com/internal/theme"
 	g "github.com/maragudk/gomponents"
 	. "github.com/maragudk/gomponents/html"
 )
 
 // UserSettings holds the user settings data.
 type UserSettings struct {
-	Email           string
-	NotificationsOn bool
-	Theme           string
+	Email           string `json:"email"`
+	NotificationsOn bool   `json:"notificationsOn"`
+	Theme           string `json:"theme"`
 }
 
-// Settings renders the settings form.
-func Settings(settings UserSettings) g.Node {
+// Settings renders the settings page content, with the user's custom themes
+// and editor, the custom theme form, below the settings form.
+func Settings(settings UserSettings, errs map[string]string, flash string, themes, custom []theme.Theme, editor g.Node) g.Node {
 	return Div(
 		Class("space-y-6"),
 		H1(Class("text-3xl font-bold text-text"), g.Text("Settings")),
-		FormEl(
-			Method("post"),
-			Action("/api/settings"),
-			Data("hx-post", "/api/settings"),
-			Data("hx-target", "this"),
-			Data("hx-swap", "outerHTML"),
-			Class("space-y-4"),
-			// Email Input
-			Div(
-				Label(
-					For("email"),
-					Class("block text-sm font-medium text-text mb-1"),
-					g.Text("Email"),
-				),
+		SettingsForm(settings, errs, flash, themes),
+		H2(Class("text-2xl font-bold text-text"), g.Text("Custom Themes")),
+		CustomThemes(custom),
+		editor,
+	)
+}
+
+// SettingsForm renders the settings form, offering themes in the theme
+// dropdown. Validation errors are keyed by field name and shown under the
+// matching input; flash is shown above the form. Extra attributes, such as an
+// out-of-band swap, are added to the form.
+func SettingsForm(settings UserSettings, errs map[string]string, flash string, themes []theme.Theme, attrs ...g.Node) g.Node {
+	return Form(
+		ID("settings-form"),
+		Method("post"),
+		Action("/api/settings"),
+		Data("hx-post", "/api/settings"),
+		Data("hx-target", "this"),
+		Data("hx-swap", "outerHTML"),
+		Class("space-y-4"),
+		g.Group(attrs),
+		g.If(flash != "",
+			P(
+				Role("status"),
+				Class("px-3 py-2 bg-secondary text-accent rounded-md"),
+				g.Text(flash),
+			),
+		),
+		// Email Input
+		Div(
+			Label(
+				For("email"),
+				Class("block text-sm font-medium text-text mb-1"),
+				g.Text("Email"),
+			),
+			Input(
+				Type("email"),
+				ID("email"),
+				Name("email"),
+				Value(settings.Email),
+				CondAttr(errs["email"] != "", "aria-invalid", "true"),
+				Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
+			),
+			FieldError(errs["email"]),
+		),
+		// Notifications Checkbox
+		Div(
+			Label(
+				Class("flex items-center"),
 				Input(
-					Type("email"),
-					ID("email"),
-					Name("email"),
-					Value(settings.Email),
-					Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
+					Type("checkbox"),
+					Name("notifications"),
+					CondAttr(settings.NotificationsOn, "checked", "checked"),
+					Class("form-checkbox h-5 w-5 text-accent"),
 				),
+				Span(Class("ml-2 text-text"), g.Text("Enable notifications")),
 			),
-			// Notifications Checkbox
-			Div(
-				Label(
-					Class("flex items-center"),
-					Input(
-						Type("checkbox"),
-						Name("notifications"),
-						CondAttr(settings.NotificationsOn, "checked", "checked"),
-						Class("form-checkbox h-5 w-5 text-accent"),
-					),
-					Span(Class("ml-2 text-text"), g.Text("Enable notifications")),
-				),
+		),
+		// Theme Selection Dropdown
+		Div(
+			Label(
+				For("theme"),
+				Class("block text-sm font-medium text-text mb-1"),
+				g.Text("Theme"),
 			),
-			// Theme Selection Dropdown
-			Div(
-				Label(
-					For("theme"),
-					Class("block text-sm font-medium text-text mb-1"),
-					g.Text("Theme"),
+			Select(
+				ID("theme"),
+				Name("theme"),
+				CondAttr(errs["theme"] != "", "aria-invalid", "true"),
+				Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
+				Option(
+					Value(""),
+					CondAttr(settings.Theme == "", "selected", "selected"),
+					g.Text("System"),
 				),
-				Select(
-					ID("theme"),
-					Name("theme"),
-					Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
-					Option(
-						Value("light"),
-						CondAttr(settings.Theme == "light", "selected", "selected"),
-						g.Text("Light"),
-					),
-					Option(
-						Value("dark"),
-						CondAttr(settings.Theme == "dark", "selected", "selected"),
-						g.Text("Dark"),
-					),
-				),
-			),
-			// Save Settings Button
-			Button(
-				Type("submit"),
-				Class("px-4 py-2 bg-accent text-primary rounded-md hover:bg-opacity-80 transition-colors duration-200"),
-				g.Text("Save Settings"),
+				g.Map(themes, func(t theme.Theme) g.Node {
+					return Option(
+						Value(t.Name),
+						CondAttr(settings.Theme == t.Name, "selected", "selected"),
+						g.Text(t.Label),
+					)
+				}),
 			),
+			FieldError(errs["theme"]),
+		),
+		// Save Settings Button
+		Button(
+			Type("submit"),
+			Class("px-4 py-2 bg-accent text-primary rounded-md hover:bg-opacity-80 transition-colors duration-200"),
+			g.Text("Save Settings"),
 		),
 	)
 }
 
+// FieldError renders an inline validation message, or nothing if msg is empty.
+func FieldError(msg string) g.Node {
+	if msg == "" {
+		return nil
+	}
+	return P(Class("mt-1 text-sm text-red-500"), g.Text(msg))
+}
+
 // CondAttr is a helper function to add an attribute conditionally.
 func CondAttr(condition bool, attrName string, attrValue string) g.Node {
 	if condition {
@@ -1,12 +1,16 @@
 package components
 
 import (
+	"alexdunmow.com/internal/nav"
+	"alexdunmow.com/internal/theme"
 	g "github.com/maragudk/gomponents"
 	. "github.com/maragudk/gomponents/html"
 )
 
-// Sidebar renders the sidebar component with the given active link.
-func Sidebar(activeLink string) g.Node {
+// Sidebar renders the sidebar with a link for each navigation item,
+// highlighting the one whose key is activeLink, the logout button for
+// signed-in users and the theme toggle.
+func Sidebar(links []nav.Item, activeLink, currentTheme string, signedIn bool) g.Node {
 	return Aside(
 		ID("sidebar"),
 		Class("bg-primary text-text w-64 min-h-screen p-4 flex flex-col"),
@@ -16,71 +20,9 @@ func Sidebar(activeLink string) g.Node {
 		),
 		Nav(
 			Class("flex-grow"),
-			Ul(
-				Class("space-y-2"),
-				Li(
-					A(
-						Href("/"),
-						Class(conditionalClass(
-							"sidebar-link block py-2 px-4 rounded transition-colors duration-200",
-							"active-link", activeLink == "home" || activeLink == "",
-							"hover:bg-secondary", activeLink != "home" && activeLink != "",
-						)),
-						Data("hx-get", "/"),
-						Data("hx-push-url", "true"),
-						Data("hx-target", "#main-content"),
-						Span(Class("inline-block w-6 mr-2"), g.Text("🏠")),
-						g.Text(" Home"),
-					),
-				),
-				Li(
-					A(
-						Href("/dashboard"),
-						Class(conditionalClass(
-							"sidebar-link block py-2 px-4 rounded transition-colors duration-200",
-							"active-link", activeLink == "dashboard" || activeLink == "",
-							"hover:bg-secondary", activeLink != "dashboard" && activeLink != "",
-						)),
-						Data("hx-get", "/dashboard"),
-						Data("hx-push-url", "true"),
-						Data("hx-target", "#main-content"),
-						Span(Class("inline-block w-6 mr-2"), g.Text("📊")),
-						g.Text(" Dashboard"),
-					),
-				),
-				Li(
-					A(
-						Href("/skills"),
-						Class(conditionalClass(
-							"sidebar-link block py-2 px-4 rounded transition-colors duration-200",
-							"active-link", activeLink == "skills",
-							"hover:bg-secondary", activeLink != "skills",
-						)),
-						Data("hx-get", "/skills"),
-						Data("hx-push-url", "true"),
-						Data("hx-target", "#main-content"),
-						Span(Class("inline-block w-6 mr-2"), g.Text("⚙️")),
-						g.Text(" Skill Tree"),
-					),
-				),
-				Li(
-					A(
-						Href("/settings"),
-						Class(conditionalClass(
-							"sidebar-link block py-2 px-4 rounded transition-colors duration-200",
-							"active-link", activeLink == "settings",
-							"hover:bg-secondary", activeLink != "settings",
-						)),
-						Data("hx-get", "/settings"),
-						Data("hx-push-url", "true"),
-						Data("hx-target", "#main-content"),
-						Span(Class("inline-block w-6 mr-2"), g.Text("⚙️")),
-						g.Text(" Settings"),
-					),
-				),
-			),
+			SidebarLinks(links, activeLink),
 		),
-		Div(
+		g.If(signedIn, Div(
 			Class("mt-auto pt-4 border-t border-secondary"),
 			Button(
 				Data("hx-post", "/logout"),
@@ -89,22 +31,81 @@ func Sidebar(activeLink string) g.Node {
 				Span(Class("inline-block w-6 mr-2"), g.Text("🚪")),
 				g.Text(" Logout"),
 			),
-		),
-		Button(
-			ID("theme-switcher"),
-			Class("mt-4 py-2 px-4 bg-accent text-primary rounded hover:bg-opacity-80 transition-colors duration-200"),
+		)),
+		ThemeToggle(currentTheme),
+	)
+}
+
+// ThemeToggle renders the button that switches theme and saves the choice.
+// Without a saved theme the page follows the browser's colour scheme, so
+// there is a button for each case and CSS shows the one that applies. Extra
+// attributes, such as an out-of-band swap, are added to the form.
+func ThemeToggle(current string, attrs ...g.Node) g.Node {
+	button := func(to, show string) g.Node {
+		class := "mt-4 py-2 px-4 bg-accent text-primary rounded hover:bg-opacity-80 transition-colors duration-200"
+		if show != "" {
+			class += " " + show
+		}
+		return Button(
+			Type("submit"),
+			Name("theme"),
+			Value(to),
+			Class(class),
+			Aria("label", "Switch to the "+to+" theme"),
 			g.Text("Toggle Theme"),
-		),
-		Script(g.Raw(`
-			if (document.readyState === "complete") {
-				window.alexdunmow.initSidebar();
-			} else {
-				window.addEventListener('DOMContentLoaded',function() {
-					console.log("side bar init");
-					window.alexdunmow.initSidebar();
-				});
-			}
-        `)),
+		)
+	}
+
+	var buttons g.Node
+	if next := theme.Toggle(current); next != "" {
+		buttons = button(next, "")
+	} else {
+		buttons = g.Group{
+			button(theme.Dark, "show-if-system-light"),
+			button(theme.Light, "show-if-system-dark"),
+		}
+	}
+
+	return Form(
+		ID("theme-switcher"),
+		Method("post"),
+		Action("/theme"),
+		Data("hx-post", "/theme"),
+		Data("hx-swap", "outerHTML"),
+		g.Group(attrs),
+		buttons,
+	)
+}
+
+// SidebarLinks renders the list of sidebar links, highlighting the one whose
+// key is activeLink. Extra attributes, such as an out-of-band swap, are added
+// to the list.
+func SidebarLinks(links []nav.Item, activeLink string, attrs ...g.Node) g.Node {
+	return Ul(
+		ID("sidebar-links"),
+		Class("space-y-2"),
+		g.Group(attrs),
+		g.Map(links, func(item nav.Item) g.Node {
+			return Li(SidebarLink(item, item.Key == activeLink))
+		}),
+	)
+}
+
+// SidebarLink renders the sidebar link for a navigation item.
+func SidebarLink(item nav.Item, active bool) g.Node {
+	return A(
+		Href(item.Path),
+		Class(conditionalClass(
+			"sidebar-link block py-2 px-4 rounded transition-colors duration-200",
+			"active-link", active,
+			"hover:bg-secondary", !active,
+		)),
+		g.If(active, Aria("current", "page")),
+		Data("hx-get", item.Path),
+		Data("hx-push-url", "true"),
+		Data("hx-target", "#main-content"),
+		Span(Class("inline-block w-6 mr-2"), g.Text(item.Icon)),
+		g.Text(" "+item.Label),
 	)
 }
 
@@ -0,0 +1,121 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"alexdunmow.com/internal/skills"
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+// SkillData holds everything shown on a skill's page.
+type SkillData struct {
+	Skill    skills.Skill
+	Parents  []skills.Skill
+	Children []skills.Skill
+	Siblings []skills.Skill
+	// Paths are the breadcrumb trails from the root skill down to this one.
+	Paths [][]skills.Skill
+}
+
+// SkillDetail renders a single skill's page.
+func SkillDetail(data SkillData) g.Node {
+	return Div(
+		Class("space-y-6"),
+		g.Map(data.Paths, Breadcrumb),
+		Div(
+			Class("flex items-center space-x-4"),
+			Span(Class("text-5xl"), Aria("hidden", "true"), g.Text(data.Skill.Icon)),
+			Div(
+				H1(Class("text-3xl font-bold text-text"), g.Text(data.Skill.Name)),
+				LoveLevel(data.Skill.Love),
+			),
+		),
+		Div(
+			Class("grid grid-cols-1 md:grid-cols-3 gap-4"),
+			SkillList("Parents", "This is a top level skill.", data.Parents),
+			SkillList("Children", "Nothing more specific under this skill.", data.Children),
+			SkillList("Related", "No related skills.", data.Siblings),
+		),
+	)
+}
+
+// Breadcrumb renders one trail of skills, each linking to its page except the last.
+func Breadcrumb(path []skills.Skill) g.Node {
+	crumbs := make([]g.Node, 0, 2*len(path))
+	for i, skill := range path {
+		if i > 0 {
+			crumbs = append(crumbs, Span(Class("mx-2"), Aria("hidden", "true"), g.Text("›")))
+		}
+		if i == len(path)-1 {
+			crumbs = append(crumbs, Span(Aria("current", "page"), g.Text(skill.Name)))
+		} else {
+			crumbs = append(crumbs, ContentLink(SkillURL(skill.Name), Class("hover:text-accent"), g.Text(skill.Name)))
+		}
+	}
+	return Nav(
+		Class("text-sm text-text"),
+		Aria("label", "Breadcrumb"),
+		g.Group(crumbs),
+	)
+}
+
+// LoveLevel renders a love level as filled and empty hearts.
+func LoveLevel(love int) g.Node {
+	love = min(max(love, 0), skills.MaxLove)
+	return P(
+		Class("text-lg"),
+		Aria("label", fmt.Sprintf("Love %d of %d", love, skills.MaxLove)),
+		TitleAttr(fmt.Sprintf("Love %d of %d", love, skills.MaxLove)),
+		g.Text(strings.Repeat("♥", love)+strings.Repeat("♡", skills.MaxLove-love)),
+	)
+}
+
+// SkillList renders a card listing skills as links, or empty when there are none.
+func SkillList(title, empty string, list []skills.Skill) g.Node {
+	var body g.Node = P(Class("text-sm text-text"), g.Text(empty))
+	if len(list) > 0 {
+		body = Ul(
+			Class("space-y-1"),
+			g.Map(list, func(skill skills.Skill) g.Node {
+				return Li(
+					ContentLink(
+						SkillURL(skill.Name),
+						Class("block py-1 px-2 rounded hover:bg-primary transition-colors duration-200"),
+						Span(Class("inline-block w-6 mr-2"), g.Text(skill.Icon)),
+						g.Text(skill.Name),
+					),
+				)
+			}),
+		)
+	}
+
+	return Div(
+		Class("bg-secondary p-4 rounded-lg shadow-md"),
+		H2(Class("text-lg font-semibold text-text mb-2"), g.Text(title)),
+		body,
+	)
+}
+
+// SkillNotFound renders the page for a skill name that isn't in the graph.
+func SkillNotFound(name string) g.Node {
+	return Div(
+		Class("space-y-6"),
+		H1(Class("text-3xl font-bold text-text"), g.Text("Skill not found")),
+		P(Class("text-text"), g.Textf("There is no skill called %q.", name)),
+		ContentLink("/skills", Class("text-accent hover:underline"), g.Text("Back to the skill tree")),
+	)
+}
+
+// ContentLink renders a link that HTMX loads into #main-content, pushing the
+// URL to history. It is a plain link when JavaScript is off.
+func ContentLink(href string, children ...g.Node) g.Node {
+	return A(
+		Href(href),
+		Data("hx-get", href),
+		Data("hx-push-url", "true"),
+		Data("hx-target", "#main-content"),
+		g.Group(children),
+	)
+}
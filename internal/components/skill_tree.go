@@ -1,10 +1,53 @@
 package components
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+
+	"alexdunmow.com/internal/skills"
 	g "github.com/maragudk/gomponents"
 	. "github.com/maragudk/gomponents/html"
+	svg "github.com/maragudk/gomponents/svg"
 )
 
+// Skills renders the skills page content: the server-rendered SVG tree by
+// default, or the interactive canvas when view is "canvas".
+func Skills(diagram *skills.Diagram, view string) g.Node {
+	tab := func(label, href string, active bool) g.Node {
+		return A(
+			Href(href),
+			Class(conditionalClass(
+				"py-1 px-3 rounded transition-colors duration-200",
+				"active-link", active,
+				"hover:bg-secondary", !active,
+			)),
+			g.Text(label),
+		)
+	}
+
+	content := SkillTreeSVG(diagram)
+	if view == "canvas" {
+		content = SkillTree()
+	}
+
+	return Div(
+		Class("space-y-4 h-full flex flex-col"),
+		Div(
+			Class("flex items-center justify-between"),
+			H1(Class("text-3xl font-bold text-text"), g.Text("Skill Tree")),
+			Nav(
+				Class("flex space-x-2 text-sm"),
+				Aria("label", "Skill tree view"),
+				tab("Diagram", "/skills", view != "canvas"),
+				tab("Interactive", "/skills?view=canvas", view == "canvas"),
+			),
+		),
+		content,
+	)
+}
+
 // SkillTree renders the skill tree component with a canvas and JavaScript initialization.
 func SkillTree() g.Node {
 	return Div(
@@ -13,10 +56,96 @@ func SkillTree() g.Node {
 			ID("skillTreeCanvas"),
 			Class("w-full h-full"),
 		),
+		// Boosted navigation swaps this in after DOMContentLoaded has fired,
+		// so only wait for it on a full page load.
 		Script(g.Raw(`
-            window.addEventListener('DOMContentLoaded', function() {
-                alexdunmow.initSkillTree(document.getElementById('skillTreeCanvas'));
-            });
+			if (document.readyState !== "loading") {
+				window.alexdunmow.initSkillTree(document.getElementById('skillTreeCanvas'));
+			} else {
+				window.addEventListener('DOMContentLoaded', function() {
+					window.alexdunmow.initSkillTree(document.getElementById('skillTreeCanvas'));
+				});
+			}
         `)),
 	)
 }
+
+// maxLabel is the longest skill name, in characters, that fits in a node.
+const maxLabel = 24
+
+// SkillTreeSVG renders a laid out skill graph as inline SVG. Every node links
+// to its skill's page, so the tree works without JavaScript.
+func SkillTreeSVG(diagram *skills.Diagram) g.Node {
+	edges := make([]g.Node, 0, len(diagram.Edges))
+	for _, e := range diagram.Edges {
+		midY := (e.Y1 + e.Y2) / 2
+		edges = append(edges, svg.Path(
+			svg.D(fmt.Sprintf("M %d %d C %d %d, %d %d, %d %d", e.X1, e.Y1, e.X1, midY, e.X2, midY, e.X2, e.Y2)),
+			svg.Fill("none"),
+			g.Attr("style", "stroke: var(--color-secondary)"),
+			svg.StrokeWidth("2"),
+		))
+	}
+
+	nodes := make([]g.Node, 0, len(diagram.Nodes))
+	for _, n := range diagram.Nodes {
+		nodes = append(nodes, skillNode(n))
+	}
+
+	return Div(
+		ID("skill-tree"),
+		Class("w-full flex-grow overflow-auto"),
+		svg.SVG(
+			Width(strconv.Itoa(diagram.Width)),
+			Height(strconv.Itoa(diagram.Height)),
+			svg.ViewBox(fmt.Sprintf("0 0 %d %d", diagram.Width, diagram.Height)),
+			Role("group"),
+			Aria("label", "Skill tree"),
+			g.El("g", g.Group(edges)),
+			g.El("g", g.Group(nodes)),
+		),
+	)
+}
+
+func skillNode(n skills.DiagramNode) g.Node {
+	x := n.X - skills.NodeWidth/2
+	y := n.Y - skills.NodeHeight/2
+
+	label := n.Skill.Name
+	if utf8.RuneCountInString(label) > maxLabel {
+		label = string([]rune(label)[:maxLabel-1]) + "…"
+	}
+
+	return g.El("a",
+		Href(SkillURL(n.Skill.Name)),
+		g.El("title", g.Textf("%s (love %d)", n.Skill.Name, n.Skill.Love)),
+		g.El("rect",
+			g.Attr("x", strconv.Itoa(x)),
+			g.Attr("y", strconv.Itoa(y)),
+			Width(strconv.Itoa(skills.NodeWidth)),
+			Height(strconv.Itoa(skills.NodeHeight)),
+			g.Attr("rx", "10"),
+			g.Attr("style", "fill: var(--color-primary); stroke: var(--color-accent)"),
+			// Better loved skills get a heavier border.
+			svg.StrokeWidth(strconv.Itoa(max(n.Skill.Love-1, 1))),
+		),
+		svgText(n.X, n.Y-8, "18", n.Skill.Icon),
+		svgText(n.X, n.Y+16, "13", label),
+	)
+}
+
+func svgText(x, y int, size, text string) g.Node {
+	return g.El("text",
+		g.Attr("x", strconv.Itoa(x)),
+		g.Attr("y", strconv.Itoa(y)),
+		g.Attr("text-anchor", "middle"),
+		g.Attr("font-size", size),
+		g.Attr("style", "fill: var(--color-text)"),
+		g.Text(text),
+	)
+}
+
+// SkillURL returns the path of a skill's page.
+func SkillURL(name string) string {
+	return "/skills/" + url.PathEscape(name)
+}
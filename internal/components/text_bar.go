@@ -5,7 +5,8 @@ import (
 	. "github.com/maragudk/gomponents/html"
 )
 
-// ChatSidebar renders the chat sidebar component.
+// ChatSidebar renders the chat sidebar component. The remembered messages and
+// new ones all arrive over the /chat/events stream.
 func ChatSidebar() g.Node {
 	return Aside(
 		ID("chat-sidebar"),
@@ -26,46 +27,76 @@ func ChatSidebar() g.Node {
 		Div(
 			ID("chat-messages"),
 			Class("flex-grow overflow-y-auto space-y-2 border-b border-secondary mb-4 p-2"),
-			// Example messages
-			Div(
-				Class("bg-primary p-2 rounded-lg shadow"),
-				P(
-					Class("text-sm"),
-					Strong(g.Text("User1:")),
-					g.Text(" Hey there! How's it going?"),
-				),
-			),
-			Div(
-				Class("bg-accent p-2 rounded-lg shadow self-end text-primary"),
-				P(
-					Class("text-sm"),
-					Strong(g.Text("You:")),
-					g.Text(" All good here. What about you?"),
-				),
-			),
+			Aria("live", "polite"),
 		),
 		// Input Box
+		ChatForm("", ""),
+		Script(g.Raw(`
+			if (document.readyState !== "loading") {
+				window.alexdunmow.initChatSidebar();
+			} else {
+				window.addEventListener('DOMContentLoaded', function() {
+					window.alexdunmow.initChatSidebar();
+				});
+			}
+        `)),
+	)
+}
+
+// ChatForm renders the form for sending a chat message, holding message and
+// showing errMsg, if any, under it.
+func ChatForm(message, errMsg string) g.Node {
+	return Form(
+		ID("chat-form"),
+		Method("post"),
+		Action("/send-message"),
+		Data("hx-post", "/send-message"),
+		Data("hx-target", "this"),
+		Data("hx-swap", "outerHTML"),
 		Div(
 			Class("flex items-center space-x-2"),
 			Input(
 				Type("text"),
 				ID("chat-input"),
+				Name("message"),
+				Value(message),
 				Class("flex-grow p-2 border rounded bg-primary text-text"),
 				Placeholder("Type a message..."),
+				AutoComplete("off"),
+				MaxLength("500"),
+				Required(),
+				CondAttr(errMsg != "", "aria-invalid", "true"),
 			),
 			Button(
+				Type("submit"),
 				ID("send-message"),
 				Class("py-2 px-4 bg-accent text-primary rounded hover:bg-opacity-80 transition-colors duration-200"),
-				Data("hx-post", "/send-message"),
-				// Inline hx-vals JavaScript cannot be passed directly, so add a placeholder and define in script
-				Data("hx-vals", `{'message': document.getElementById('chat-input').value}`),
-				Data("hx-target", "#chat-messages"),
 				g.Text("Send"),
 			),
 		),
-		// Script (JavaScript can be embedded as plain text)
-		Script(g.Raw(`
-            // window.alexdunmow.initChatSidebar();
-        `)),
+		FieldError(errMsg),
+	)
+}
+
+// ChatMessage renders a single chat message. The viewer's own messages are
+// highlighted and attributed to "You".
+func ChatMessage(author, text string, mine bool) g.Node {
+	if mine {
+		return Div(
+			Class("bg-accent p-2 rounded-lg shadow self-end text-primary"),
+			P(
+				Class("text-sm"),
+				Strong(g.Text("You:")),
+				g.Text(" "+text),
+			),
+		)
+	}
+	return Div(
+		Class("bg-primary p-2 rounded-lg shadow"),
+		P(
+			Class("text-sm"),
+			Strong(g.Text(author+":")),
+			g.Text(" "+text),
+		),
 	)
 }
@@ -0,0 +1,171 @@
+package components
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"alexdunmow.com/internal/theme"
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+// ThemeEditor renders the form for creating a custom theme from draft.
+// Changing a colour previews the palette without saving it. Validation errors
+// are keyed by field name; flash is shown above the form.
+func ThemeEditor(draft theme.Theme, errs map[string]string, flash string) g.Node {
+	return Form(
+		ID("theme-editor"),
+		Method("post"),
+		Action("/api/themes"),
+		Data("hx-post", "/api/themes"),
+		Data("hx-target", "this"),
+		Data("hx-swap", "outerHTML"),
+		Class("space-y-4"),
+		g.If(flash != "",
+			P(
+				Role("status"),
+				Class("px-3 py-2 bg-secondary text-accent rounded-md"),
+				g.Text(flash),
+			),
+		),
+		Div(
+			Label(
+				For("theme-label"),
+				Class("block text-sm font-medium text-text mb-1"),
+				g.Text("Name"),
+			),
+			Input(
+				Type("text"),
+				ID("theme-label"),
+				Name("label"),
+				Value(draft.Label),
+				MaxLength(strconv.Itoa(theme.MaxLabel)),
+				CondAttr(errs["label"] != "", "aria-invalid", "true"),
+				Class("w-full px-3 py-2 bg-secondary text-text rounded-md focus:outline-none focus:ring-2 focus:ring-accent"),
+			),
+			FieldError(errs["label"]),
+		),
+		FieldSet(
+			Data("hx-post", "/api/themes/preview"),
+			Data("hx-trigger", "input delay:150ms"),
+			Data("hx-target", "#theme-preview"),
+			Data("hx-swap", "outerHTML"),
+			Class("grid grid-cols-2 gap-4 sm:grid-cols-5"),
+			Legend(Class("sr-only"), g.Text("Colours")),
+			g.Map(draft.Palette.Colors(), func(c theme.Color) g.Node {
+				return Div(
+					Label(
+						For("theme-"+c.Field),
+						Class("block text-sm font-medium text-text mb-1"),
+						g.Text(c.Label),
+					),
+					Input(
+						Type("color"),
+						ID("theme-"+c.Field),
+						Name(c.Field),
+						Value(c.Value),
+						CondAttr(errs[c.Field] != "", "aria-invalid", "true"),
+						Class("w-full h-10 bg-secondary rounded-md"),
+					),
+					FieldError(errs[c.Field]),
+				)
+			}),
+		),
+		ThemePreview(draft.Palette),
+		Button(
+			Type("submit"),
+			Class("px-4 py-2 bg-accent text-primary rounded-md hover:bg-opacity-80 transition-colors duration-200"),
+			g.Text("Create Theme"),
+		),
+	)
+}
+
+// CustomThemes lists the user's custom themes, each with a button deleting
+// it. Extra attributes, such as an out-of-band swap, are added to the list.
+func CustomThemes(custom []theme.Theme, attrs ...g.Node) g.Node {
+	return Div(
+		ID("custom-themes"),
+		g.Group(attrs),
+		g.If(len(custom) == 0,
+			P(Class("text-text"), g.Textf("You have no custom themes yet. You can create up to %d.", theme.MaxCustom)),
+		),
+		g.If(len(custom) > 0,
+			Ul(
+				Class("space-y-2"),
+				g.Map(custom, func(t theme.Theme) g.Node {
+					action := "/api/themes/" + url.PathEscape(t.Name) + "/delete"
+					return Li(
+						Class("flex items-center justify-between px-3 py-2 bg-secondary rounded-md"),
+						Span(Class("text-text"), g.Text(t.Label)),
+						Form(
+							Method("post"),
+							Action(action),
+							Data("hx-post", action),
+							Data("hx-target", "#custom-themes"),
+							Data("hx-swap", "outerHTML"),
+							Data("hx-confirm", "Delete "+t.Label+"?"),
+							Button(
+								Type("submit"),
+								Class("px-3 py-1 text-sm bg-accent text-primary rounded-md hover:bg-opacity-80 transition-colors duration-200"),
+								Aria("label", "Delete "+t.Label),
+								g.Text("Delete"),
+							),
+						),
+					)
+				}),
+			),
+		),
+	)
+}
+
+// ThemePreview renders a small mock of the site in palette p, with the
+// contrast ratio of each pair of colours the site puts together.
+func ThemePreview(p theme.Palette) g.Node {
+	var vars []string
+	for _, c := range p.Colors() {
+		// A malformed colour is left out, keeping the page's own.
+		if theme.ValidColor(c.Value) {
+			vars = append(vars, fmt.Sprintf("--color-%s: %s", c.Field, c.Value))
+		}
+	}
+
+	return Div(
+		ID("theme-preview"),
+		Class("space-y-2"),
+		Aria("live", "polite"),
+		Div(
+			Style(strings.Join(vars, "; ")),
+			Class("flex overflow-hidden rounded-md border border-secondary"),
+			Div(
+				Class("w-1/3 p-3 space-y-1 bg-primary text-text"),
+				P(g.Text("Sidebar")),
+				P(Class("px-2 rounded active-link"), g.Text("Active link")),
+			),
+			Div(
+				Class("flex-1 p-3 space-y-2 bg-background text-text"),
+				H3(Class("text-xl font-bold text-accent"), g.Text("Heading")),
+				P(g.Text("Body text as it appears on every page.")),
+				Button(
+					Type("button"),
+					Class("px-3 py-1 bg-accent text-primary rounded-md"),
+					g.Text("Button"),
+				),
+			),
+		),
+		Ul(
+			Class("text-sm space-y-1"),
+			g.Map(p.Checks(), func(c theme.Check) g.Node {
+				mark, class := "✓", "text-text"
+				if !c.OK() {
+					mark, class = "✗", "text-red-500"
+				}
+				return Li(
+					Class(class),
+					g.Textf("%s %s: %.2f:1 (needs %g:1)", mark, c.Label, c.Ratio, c.Min),
+				)
+			}),
+		),
+	)
+}
@@ -0,0 +1,325 @@
+// Package config loads the server's settings from .env, the environment and
+// command line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds every setting the server reads at startup.
+type Config struct {
+	Host string
+	Port int
+	// Dev serves files from disk rather than the copies built into the binary.
+	Dev bool
+
+	// StaticDir and SkillsFile are only read in Dev mode.
+	StaticDir    string
+	SkillsFile   string
+	SettingsFile string
+	ThemesFile   string
+	UsersFile    string
+	ProgressFile string
+	// ChatLog is where chat messages are appended; empty keeps them in memory.
+	ChatLog string
+
+	LogLevel  slog.Level
+	LogFormat string
+	// TrustedProxies are the peers whose X-Forwarded-For header is believed.
+	TrustedProxies []netip.Prefix
+
+	// SessionSecret signs session cookies. Empty means a random key per run.
+	SessionSecret string
+	AdminEmail    string
+	AdminPassword string
+
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Addr returns the address to listen on.
+func (c *Config) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// TLS reports whether the server should serve HTTPS.
+func (c *Config) TLS() bool {
+	return c.TLSCertFile != ""
+}
+
+// LogFormats are the accepted values of LOG_FORMAT.
+var LogFormats = []string{"text", "json"}
+
+// MinSessionSecret is the shortest SESSION_SECRET accepted, in bytes.
+const MinSessionSecret = 32
+
+// setting is one configuration value: its environment variable, its flag (if
+// it has one) and its default.
+type setting struct {
+	env, flag, def, usage string
+}
+
+var settings = []setting{
+	{"HOST", "host", "", "interface to listen on; empty means all"},
+	{"PORT", "port", "8080", "port to listen on"},
+	{"DEV", "dev", "false", "serve static files and the skill tree from disk"},
+	{"STATIC_DIR", "static-dir", "static", "directory of static files in dev mode"},
+	{"SKILLS_FILE", "skills-file", "skills_tree.json", "skill tree JSON file in dev mode"},
+	{"SETTINGS_FILE", "", "data/settings.json", ""},
+	{"THEMES_FILE", "", "data/themes.json", ""},
+	{"USERS_FILE", "", "data/users.json", ""},
+	{"PROGRESS_FILE", "", "data/progress.json", ""},
+	{"CHAT_LOG", "", "", ""},
+	{"LOG_LEVEL", "log-level", "info", "debug, info, warn or error"},
+	{"LOG_FORMAT", "log-format", "text", "text or json"},
+	{"TRUSTED_PROXIES", "", "", ""},
+	{"SESSION_SECRET", "", "", ""},
+	{"ADMIN_EMAIL", "", "", ""},
+	{"ADMIN_PASSWORD", "", "", ""},
+	{"TLS_CERT_FILE", "tls-cert", "", "TLS certificate file"},
+	{"TLS_KEY_FILE", "tls-key", "", "TLS private key file"},
+	{"READ_TIMEOUT", "", "10s", ""},
+	{"WRITE_TIMEOUT", "", "30s", ""},
+	{"IDLE_TIMEOUT", "", "2m", ""},
+	{"SHUTDOWN_TIMEOUT", "", "15s", ""},
+}
+
+// Error lists every invalid setting found by Load.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Load reads .env (if present), then the environment, then the flags in
+// args, each overriding the last, and validates the result. Invalid values
+// are reported together in an *Error. Usage is written to output when a flag
+// is wrong or -h is given.
+func Load(args []string, output io.Writer) (*Config, error) {
+	_ = godotenv.Load()
+
+	raw := make(map[string]string, len(settings))
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+	for _, s := range settings {
+		raw[s.env] = s.def
+		if v, ok := os.LookupEnv(s.env); ok {
+			raw[s.env] = v
+		}
+		if s.flag == "" {
+			continue
+		}
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		if s.def != "" && s.env != "DEV" {
+			usage = fmt.Sprintf("%s (env %s, default %q)", s.usage, s.env, s.def)
+		}
+		if s.env == "DEV" {
+			fs.BoolFunc(s.flag, usage, func(v string) error {
+				raw["DEV"] = v
+				return nil
+			})
+			continue
+		}
+		fs.Func(s.flag, usage, func(v string) error {
+			raw[s.env] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(output, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return nil, errors.New("unexpected arguments")
+	}
+
+	p := &parser{raw: raw}
+	c := &Config{
+		Host:            raw["HOST"],
+		Port:            p.port("PORT"),
+		Dev:             p.bool("DEV"),
+		StaticDir:       raw["STATIC_DIR"],
+		SkillsFile:      raw["SKILLS_FILE"],
+		SettingsFile:    p.required("SETTINGS_FILE"),
+		ThemesFile:      p.required("THEMES_FILE"),
+		UsersFile:       p.required("USERS_FILE"),
+		ProgressFile:    p.required("PROGRESS_FILE"),
+		ChatLog:         raw["CHAT_LOG"],
+		LogLevel:        p.level("LOG_LEVEL"),
+		LogFormat:       p.oneOf("LOG_FORMAT", LogFormats),
+		TrustedProxies:  p.proxies("TRUSTED_PROXIES"),
+		SessionSecret:   raw["SESSION_SECRET"],
+		AdminEmail:      raw["ADMIN_EMAIL"],
+		AdminPassword:   raw["ADMIN_PASSWORD"],
+		TLSCertFile:     raw["TLS_CERT_FILE"],
+		TLSKeyFile:      raw["TLS_KEY_FILE"],
+		ReadTimeout:     p.duration("READ_TIMEOUT"),
+		WriteTimeout:    p.duration("WRITE_TIMEOUT"),
+		IdleTimeout:     p.duration("IDLE_TIMEOUT"),
+		ShutdownTimeout: p.duration("SHUTDOWN_TIMEOUT"),
+	}
+
+	if c.Dev {
+		p.dir("STATIC_DIR")
+		p.file("SKILLS_FILE")
+	}
+	if c.SessionSecret != "" && len(c.SessionSecret) < MinSessionSecret {
+		p.problem("SESSION_SECRET", "must be at least %d bytes", MinSessionSecret)
+	}
+	if (c.AdminEmail == "") != (c.AdminPassword == "") {
+		p.problem("ADMIN_EMAIL", "ADMIN_EMAIL and ADMIN_PASSWORD must be set together")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		p.problem("TLS_CERT_FILE", "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	} else if c.TLS() {
+		p.file("TLS_CERT_FILE")
+		p.file("TLS_KEY_FILE")
+	}
+
+	if len(p.problems) > 0 {
+		return nil, &Error{Problems: p.problems}
+	}
+	return c, nil
+}
+
+// parser converts raw setting values, collecting a problem for each bad one
+// rather than stopping at the first.
+type parser struct {
+	raw      map[string]string
+	problems []string
+}
+
+func (p *parser) problem(key, format string, args ...any) {
+	p.problems = append(p.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (p *parser) required(key string) string {
+	v := p.raw[key]
+	if v == "" {
+		p.problem(key, "must not be empty")
+	}
+	return v
+}
+
+func (p *parser) port(key string) int {
+	n, err := strconv.Atoi(p.raw[key])
+	if err != nil || n < 1 || n > 65535 {
+		p.problem(key, "%q is not a port number between 1 and 65535", p.raw[key])
+	}
+	return n
+}
+
+func (p *parser) bool(key string) bool {
+	b, err := strconv.ParseBool(p.raw[key])
+	if err != nil {
+		p.problem(key, "%q is not true or false", p.raw[key])
+	}
+	return b
+}
+
+func (p *parser) duration(key string) time.Duration {
+	d, err := time.ParseDuration(p.raw[key])
+	if err != nil || d <= 0 {
+		p.problem(key, "%q is not a positive duration such as 30s", p.raw[key])
+	}
+	return d
+}
+
+func (p *parser) level(key string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(p.raw[key])); err != nil {
+		p.problem(key, "%q is not one of debug, info, warn or error", p.raw[key])
+	}
+	return l
+}
+
+func (p *parser) oneOf(key string, allowed []string) string {
+	v := p.raw[key]
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	p.problem(key, "%q is not one of %s", v, strings.Join(allowed, ", "))
+	return v
+}
+
+func (p *parser) file(key string) string {
+	path := p.required(key)
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		p.problem(key, "%v", err)
+	case info.IsDir():
+		p.problem(key, "%s is a directory", path)
+	}
+	return path
+}
+
+func (p *parser) dir(key string) string {
+	path := p.required(key)
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		p.problem(key, "%v", err)
+	case !info.IsDir():
+		p.problem(key, "%s is not a directory", path)
+	}
+	return path
+}
+
+// proxies parses a comma separated list of IP addresses and CIDR prefixes,
+// such as "10.0.0.0/8, 127.0.0.1".
+func (p *parser) proxies(key string) []netip.Prefix {
+	var proxies []netip.Prefix
+	for _, field := range strings.Split(p.raw[key], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if strings.Contains(field, "/") {
+			prefix, err := netip.ParsePrefix(field)
+			if err != nil {
+				p.problem(key, "%q is not an IP address or CIDR prefix", field)
+				continue
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(field)
+		if err != nil {
+			p.problem(key, "%q is not an IP address or CIDR prefix", field)
+			continue
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies
+}
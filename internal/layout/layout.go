@@ -1,30 +1,47 @@
 package layout
 
 import (
+	"alexdunmow.com/internal/assets"
 	components "alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/nav"
+	"alexdunmow.com/internal/theme"
 	g "github.com/maragudk/gomponents"
 	. "github.com/maragudk/gomponents/html"
 )
 
-// Layout template, which serves as the layout for other pages.
-func Layout(title string, activeLink string, children ...g.Node) g.Node {
+// Layout template, which serves as the layout for other pages. links are the
+// sidebar's navigation items, sel the visitor's theme and signedIn whether
+// they can log out.
+func Layout(p Page, links []nav.Item, sel theme.Selection, signedIn bool) g.Node {
+	bodyClass := "flex h-full bg-background text-text"
+	if sel.Name != "" {
+		bodyClass += " " + theme.Class(sel.Name)
+	}
+	themesCSS := sel.Stylesheet
+	if themesCSS == "" {
+		themesCSS = theme.StylesheetPath
+	}
 	return Doctype(HTML(
 		Lang("en"),
 		Class("h-full"),
 		Head(
 			Meta(Charset("UTF-8")),
 			Meta(Name("viewport"), Content("width=device-width, initial-scale=1.0")),
-			Script(Src("https://unpkg.com/htmx.org@1.9.11")),
-			Link(Rel("stylesheet"), Href("/static/css/output.css")),
-			Link(Rel("stylesheet"), Href("/static/css/theme.css")),
-			Title(title),
+			script(HTMXFile),
+			stylesheet("css/output.css"),
+			// Generated, so it has no integrity hash; the theme-changed
+			// event swaps it by ID when a theme is added.
+			Link(Rel("stylesheet"), ID("themes-css"), Href(themesCSS)),
+			stylesheet("css/theme.css"),
+			TitleEl(ID("page-title"), g.Text(p.Title)),
+			g.Group(p.Head),
 		),
 		Body(
-			Class("flex h-full bg-background text-text dark"),
+			Class(bodyClass),
 			Data("hx-boost", "true"),
 			Div(
 				ID("sidebar-container"),
-				components.Sidebar(activeLink),
+				components.Sidebar(links, p.Active, sel.Name, signedIn),
 			),
 			components.ChatSidebar(),
 			Div(
@@ -33,30 +50,28 @@ func Layout(title string, activeLink string, children ...g.Node) g.Node {
 				Main(
 					ID("main-content"),
 					Class("flex-grow p-6"),
-					g.Group(children), // Use g.Group to wrap children nodes correctly
+					p.Body,
 				),
 			),
-			Script(Src("/static/js/bundle.js")),
+			script("js/bundle.js"),
 		),
 	))
 }
 
-// HomePage template
-func HomePage() g.Node {
-	return Layout("Home", "home", components.Home())
-}
+// HTMXFile is where `npm run vendor` in static/ts copies htmx to.
+const HTMXFile = "js/htmx.min.js"
 
-// DashboardPage template
-func DashboardPage(data components.DashboardData) g.Node {
-	return Layout("Dashboard", "dashboard", components.Dashboard(data))
+// script loads a static JavaScript file with its integrity hash.
+func script(name string) g.Node {
+	return Script(Src(assets.Path(name)), integrity(name))
 }
 
-// SettingsPage template
-func SettingsPage(settings components.UserSettings) g.Node {
-	return Layout("Settings", "settings", components.Settings(settings))
+// stylesheet links a static CSS file with its integrity hash.
+func stylesheet(name string) g.Node {
+	return Link(Rel("stylesheet"), Href(assets.Path(name)), integrity(name))
 }
 
-// SkillsPage template
-func SkillsPage() g.Node {
-	return Layout("Skill Tree", "skills", components.SkillTree())
+func integrity(name string) g.Node {
+	v := assets.Integrity(name)
+	return g.If(v != "", Integrity(v))
 }
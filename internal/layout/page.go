@@ -0,0 +1,53 @@
+package layout
+
+import (
+	"net/http"
+
+	"alexdunmow.com/internal/accept"
+	components "alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/nav"
+	"alexdunmow.com/internal/theme"
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+// Page is one page of the site.
+type Page struct {
+	Title string
+	// Active is the key of the sidebar link to highlight.
+	Active string
+	// Body is the page content, shown in #main-content.
+	Body g.Node
+	// Head holds extra nodes for the document head. It is only rendered
+	// with the full document.
+	Head []g.Node
+}
+
+// Render returns p as a fragment for #main-content when r is an HTMX swap,
+// and as the full document otherwise. Fragments carry out-of-band updates
+// for the sidebar's active link and the document title, so the rest of the
+// page follows the navigation.
+func Render(w http.ResponseWriter, r *http.Request, p Page) g.Node {
+	accept.Vary(w.Header(), "HX-Request")
+	accept.Vary(w.Header(), "HX-Boosted")
+	accept.Vary(w.Header(), "HX-History-Restore-Request")
+
+	if Fragment(r) {
+		return g.Group{
+			p.Body,
+			components.SidebarLinks(nav.Links(r), p.Active, Data("hx-swap-oob", "true")),
+			TitleEl(ID("page-title"), Data("hx-swap-oob", "true"), g.Text(p.Title)),
+		}
+	} else {
+		return Layout(p, nav.Links(r), theme.FromContext(r.Context()), nav.SignedIn(r))
+	}
+}
+
+// Fragment reports whether r wants just the main content. Boosted links and
+// forms swap the whole body, and htmx restores history it has no snapshot
+// of by replacing the body too, so both need the full document.
+func Fragment(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true" &&
+		r.Header.Get("HX-Boosted") != "true" &&
+		r.Header.Get("HX-History-Restore-Request") != "true"
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"alexdunmow.com/internal/accept"
+	"github.com/andybalholm/brotli"
+)
+
+// Compress returns middleware that compresses response bodies of at least
+// minSize bytes with brotli or gzip, whichever the client prefers. Smaller
+// bodies, streams flushed before reaching minSize, already encoded responses
+// and binary content types are sent as they are.
+func Compress(minSize int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept.Vary(w.Header(), "Accept-Encoding")
+
+			encoding := accept.Encoding(r, "br", "gzip")
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        minSize,
+				head:           r.Method == http.MethodHead,
+			}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}}
+	brotliWriters = sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(nil, 4)
+	}}
+)
+
+// encoder is the part of gzip.Writer and brotli.Writer compressWriter uses.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// compressWriter holds back the start of the body until it knows whether the
+// response is worth compressing, then writes the headers and either
+// compresses or passes the body through.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	head     bool
+
+	status  int
+	buf     []byte
+	started bool
+	enc     encoder
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if status < 200 {
+		// Informational responses go straight through.
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified || cw.head {
+		cw.start()
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.started {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends what has been written so far. A response flushed before it
+// reaches minSize, such as an event stream, is sent uncompressed.
+func (cw *compressWriter) Flush() {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.started {
+		cw.start()
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Flush()
+	}
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start decides whether to compress, writes the headers and sends anything
+// buffered.
+func (cw *compressWriter) start() error {
+	cw.started = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// Sniff now: net/http would otherwise sniff the compressed bytes.
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if len(cw.buf) >= cw.minSize && cw.compressible() {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		// The compressed bytes differ, so a strong validator no longer holds.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		if cw.encoding == "br" {
+			cw.enc = brotliWriters.Get().(*brotli.Writer)
+		} else {
+			cw.enc = gzipWriters.Get().(*gzip.Writer)
+		}
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if cw.status == http.StatusPartialContent || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// close finishes the response once the handler has returned.
+func (cw *compressWriter) close() {
+	if !cw.started {
+		if cw.status == 0 {
+			// The handler wrote nothing; let net/http send its default.
+			return
+		}
+		cw.start()
+	}
+	if cw.enc == nil {
+		return
+	}
+	_ = cw.enc.Close()
+	cw.enc.Reset(nil)
+	if cw.encoding == "br" {
+		brotliWriters.Put(cw.enc)
+	} else {
+		gzipWriters.Put(cw.enc)
+	}
+	cw.enc = nil
+}
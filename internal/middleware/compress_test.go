@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+const testMinSize = 100
+
+var (
+	longText  = strings.Repeat("hello, compressed world. ", 20)
+	shortText = "hello"
+)
+
+// compress serves a request with acceptEncoding through Compress and h.
+func compress(method, acceptEncoding string, h http.HandlerFunc) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	Compress(testMinSize)(h).ServeHTTP(w, r)
+	return w
+}
+
+// decode returns w's body, decompressed according to its Content-Encoding.
+func decode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body io.Reader = w.Body
+	switch enc := w.Header().Get("Content-Encoding"); enc {
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = zr
+	case "br":
+		body = brotli.NewReader(body)
+	case "":
+	default:
+		t.Fatalf("unexpected Content-Encoding %q", enc)
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func writeBody(contentType string, status int, body ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		for _, b := range body {
+			_, _ = io.WriteString(w, b)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		handler        http.HandlerFunc
+		wantEncoding   string
+		wantType       string
+		wantBody       string
+	}{
+		{"gzip", "gzip", writeBody("text/html; charset=utf-8", 0, longText), "gzip", "text/html; charset=utf-8", longText},
+		{"brotli preferred", "gzip, br", writeBody("application/json", 0, longText), "br", "application/json", longText},
+		{"split writes", "gzip", writeBody("text/css", 0, longText[:50], longText[50:]), "gzip", "text/css", longText},
+		{"sniffed type", "gzip", writeBody("", 0, "<!DOCTYPE html>"+longText), "gzip", "text/html; charset=utf-8", "<!DOCTYPE html>" + longText},
+		{"no Accept-Encoding", "", writeBody("text/html", 0, longText), "", "text/html", longText},
+		{"too small", "gzip", writeBody("text/html", 0, shortText), "", "text/html", shortText},
+		{"binary", "gzip", writeBody("image/png", 0, longText), "", "image/png", longText},
+		{"event stream", "gzip", writeBody("text/event-stream", 0, longText), "", "text/event-stream", longText},
+		{"error page", "br", writeBody("text/html", http.StatusNotFound, longText), "br", "text/html", longText},
+		{"partial content", "gzip", writeBody("text/plain", http.StatusPartialContent, longText), "", "text/plain", longText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := compress(http.MethodGet, tt.acceptEncoding, tt.handler)
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := decode(t, w); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestCompressHeaders(t *testing.T) {
+	w := compress(http.MethodGet, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "500")
+		w.Header().Set("ETag", `"abc"`)
+		_, _ = io.WriteString(w, longText)
+	})
+	if got := w.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length = %q, want it dropped", got)
+	}
+	if got := w.Header().Get("ETag"); got != `W/"abc"` {
+		t.Errorf("ETag = %q, want it weakened", got)
+	}
+
+	w = compress(http.MethodGet, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = io.WriteString(w, longText)
+	})
+	if w.Body.String() != longText {
+		t.Errorf("an already encoded body was changed: %q", w.Body)
+	}
+}
+
+func TestCompressNoBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"nothing written", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK},
+		{"no content", http.MethodGet, writeBody("text/html", http.StatusNoContent), http.StatusNoContent},
+		{"not modified", http.MethodGet, writeBody("text/html", http.StatusNotModified), http.StatusNotModified},
+		{"redirect", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/elsewhere", http.StatusSeeOther)
+		}, http.StatusSeeOther},
+		{"HEAD", http.MethodHead, writeBody("text/html", http.StatusOK), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := compress(tt.method, "gzip", tt.handler)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	t.Run("before minSize", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		var flushedEarly string
+		h := Compress(testMinSize)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(rw, "data: hi\n\n")
+			if err := http.NewResponseController(rw).Flush(); err != nil {
+				t.Errorf("Flush() = %v", err)
+			}
+			flushedEarly = w.Body.String()
+			_, _ = io.WriteString(rw, longText)
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		h.ServeHTTP(w, r)
+
+		if flushedEarly != "data: hi\n\n" {
+			t.Errorf("body after Flush = %q, want the event sent at once", flushedEarly)
+		}
+		if !w.Flushed || w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("Flushed = %v, Content-Encoding = %q, want flushed and uncompressed", w.Flushed, w.Header().Get("Content-Encoding"))
+		}
+		if got := w.Body.String(); got != "data: hi\n\n"+longText {
+			t.Errorf("body = %q", got)
+		}
+	})
+
+	t.Run("once compressing", func(t *testing.T) {
+		w := compress(http.MethodGet, "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, longText)
+			_ = http.NewResponseController(w).Flush()
+			_, _ = io.WriteString(w, shortText)
+		})
+		if !w.Flushed || w.Header().Get("Content-Encoding") != "gzip" {
+			t.Errorf("Flushed = %v, Content-Encoding = %q, want flushed and gzipped", w.Flushed, w.Header().Get("Content-Encoding"))
+		}
+		if got := decode(t, w); got != longText+shortText {
+			t.Errorf("body = %q, want %q", got, longText+shortText)
+		}
+	})
+}
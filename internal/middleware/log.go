@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Log returns middleware that writes one structured access log record per
+// request. It must run inside Context so the request ID and user are known.
+// X-Forwarded-For is only believed when the connection comes from one of
+// proxies.
+func Log(logger *slog.Logger, proxies []netip.Prefix) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := NewResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			status := rw.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if status >= 500 {
+				level = slog.LevelError
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("bytes", rw.Bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_ip", ClientIP(r, proxies)),
+				slog.String("user_agent", r.UserAgent()),
+				slog.Bool("htmx", r.Header.Get("HX-Request") == "true"),
+			}
+			if target := r.Header.Get("HX-Target"); target != "" {
+				attrs = append(attrs, slog.String("htmx_target", target))
+			}
+			if ctx := FromContext(r.Context()); ctx != nil {
+				attrs = append(attrs, slog.String("request_id", ctx.RequestID))
+				if ctx.User != nil {
+					attrs = append(attrs, slog.String("user_id", ctx.User.ID))
+				}
+			}
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// ClientIP returns the address of the client that made r. When the direct
+// peer is a trusted proxy, X-Forwarded-For is read right to left and the
+// first address that isn't a trusted proxy wins.
+func ClientIP(r *http.Request, proxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !trusted(addr, proxies) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Anything before a malformed entry can't be trusted.
+			break
+		}
+		addr = hop
+		if !trusted(hop, proxies) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
+
+func trusted(addr netip.Addr, proxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,48 +2,217 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"net/http"
 	"time"
+
+	"alexdunmow.com/internal/auth"
 )
 
 type CustomContext struct {
 	context.Context
 	StartTime time.Time
+	RequestID string
+	User      *auth.User
 }
 
 type CustomHandler func(ctx *CustomContext, w http.ResponseWriter, r *http.Request)
 type CustomMiddleware func(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error
 
-func Chain(w http.ResponseWriter, r *http.Request, handlerFunc http.HandlerFunc, middleware ...CustomMiddleware) {
-	customContext := &CustomContext{
-		Context:   context.Background(),
-		StartTime: time.Now(),
+// Middleware wraps an http.Handler with extra behaviour.
+type Middleware func(http.Handler) http.Handler
+
+type contextKey struct{}
+
+// Chain wraps h in middleware. The first middleware is the outermost, so it
+// sees the request first and the response last.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
 	}
-	for _, mw := range middleware {
-		err := mw(customContext, w, r)
-		if err != nil {
-			return
+	return h
+}
+
+// Context attaches a CustomContext to the request. It is derived from the
+// request's own context, so cancellation and deadlines still reach handlers.
+func Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := &CustomContext{StartTime: time.Now()}
+		ctx.Context = context.WithValue(r.Context(), contextKey{}, ctx)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestID sets ctx.RequestID from the incoming X-Request-ID header, or to a
+// new ID if the header is missing or malformed, and echoes it in the
+// response. It must run inside Context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		if ctx := FromContext(r.Context()); ctx != nil {
+			ctx.RequestID = id
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID accepts up to 128 letters, digits, dots, dashes and
+// underscores, so a client can't inject anything odd into logs or pages.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '-', c == '_':
+		default:
+			return false
 		}
 	}
-	r = r.WithContext(customContext)
-	handlerFunc(w, r)
-	Log(customContext, w, r)
+	return true
 }
 
-func Log(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error {
-	elapsedTime := time.Since(ctx.StartTime)
-	formattedTime := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Printf("[%s] [%s] [%s] [%s]\n", formattedTime, r.Method, r.URL.Path, elapsedTime)
-	return nil
+// newRequestID returns a random 16 character hex ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// FromContext returns the CustomContext attached by Context, or nil.
+func FromContext(ctx context.Context) *CustomContext {
+	c, _ := ctx.Value(contextKey{}).(*CustomContext)
+	return c
+}
+
+// Adapt turns a CustomMiddleware into a Middleware. If mw returns an error the
+// request stops there; mw is expected to have written the response. Adapt must
+// run inside Context.
+func Adapt(mw CustomMiddleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := FromContext(r.Context())
+			if ctx == nil {
+				http.Error(w, "middleware: missing request context", http.StatusInternalServerError)
+				return
+			}
+			if err := mw(ctx, w, r); err != nil {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ResponseWriter records the status code and body size written through it.
+type ResponseWriter struct {
+	http.ResponseWriter
+	Status int
+	Bytes  int64
+}
+
+// NewResponseWriter wraps w, reusing it if it is already a *ResponseWriter.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (w *ResponseWriter) WriteHeader(status int) {
+	if w.Status == 0 {
+		w.Status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.Bytes += int64(n)
+	return n, err
+}
+
+// Flush lets streaming handlers such as server-sent events work through the
+// wrapper.
+func (w *ResponseWriter) Flush() {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 func ParseForm(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error {
-	r.ParseForm()
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form data", http.StatusBadRequest)
+		return err
+	}
 	return nil
 }
 
 func ParseMultipartForm(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error {
-	r.ParseMultipartForm(10 << 20)
+	if err := r.ParseMultipartForm(10 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "invalid form data", http.StatusBadRequest)
+		return err
+	}
 	return nil
 }
+
+// LoadUser returns middleware that sets ctx.User from the request's session
+// cookie. Requests without a valid session continue with a nil User.
+func LoadUser(users auth.UserStore, sessions *auth.Sessions) CustomMiddleware {
+	return func(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error {
+		id, ok := sessions.UserID(r)
+		if !ok {
+			return nil
+		}
+		user, err := users.ByID(id)
+		if err != nil {
+			// The account behind a still-valid cookie is gone; treat the
+			// request as anonymous.
+			return nil
+		}
+		ctx.User = user
+		return nil
+	}
+}
+
+// RequireUser stops the chain for anonymous requests and sends them to the
+// login page. It must run after LoadUser.
+func RequireUser(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error {
+	if ctx.User != nil {
+		return nil
+	}
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", auth.LoginURL(r))
+		w.WriteHeader(http.StatusUnauthorized)
+	} else {
+		http.Redirect(w, r, auth.LoginURL(r), http.StatusSeeOther)
+	}
+	return errUnauthenticated
+}
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+// CurrentUser returns the user loaded by LoadUser, or nil for anonymous requests.
+func CurrentUser(r *http.Request) *auth.User {
+	ctx := FromContext(r.Context())
+	if ctx == nil {
+		return nil
+	}
+	return ctx.User
+}
@@ -0,0 +1,109 @@
+// Package nav keeps the list of pages shown in the sidebar. Each page is
+// registered once, and both its route and its sidebar link come from that.
+package nav
+
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
+
+// Item is one page in the navigation.
+type Item struct {
+	// Key identifies the item; a layout.Page with the same Active key
+	// highlights it.
+	Key   string
+	Label string
+	Icon  string
+	Path  string
+	// Order sorts the sidebar, lowest first.
+	Order int
+	// AuthOnly hides the item from anonymous visitors and protects its route.
+	AuthOnly bool
+	// GuestOnly hides the item from signed-in users. Its route stays open,
+	// so the handler decides what a signed-in user gets there.
+	GuestOnly bool
+	// Handler serves GET requests for Path.
+	Handler http.Handler
+}
+
+// Registry holds the navigation items.
+type Registry struct {
+	// SignedIn reports whether a request comes from a signed-in user.
+	SignedIn func(*http.Request) bool
+	// Protect wraps the handlers of AuthOnly items, sending anonymous
+	// visitors elsewhere.
+	Protect func(http.Handler) http.Handler
+
+	items []Item
+}
+
+// Add registers item, keeping the items sorted by Order.
+func (reg *Registry) Add(item Item) {
+	i, _ := slices.BinarySearchFunc(reg.items, item.Order, func(it Item, order int) int {
+		return it.Order - order
+	})
+	// Place after any items with the same Order, so ties keep their
+	// registration order.
+	for i < len(reg.items) && reg.items[i].Order == item.Order {
+		i++
+	}
+	reg.items = slices.Insert(reg.items, i, item)
+}
+
+// Items returns every item in sidebar order.
+func (reg *Registry) Items() []Item {
+	return slices.Clone(reg.items)
+}
+
+// Links returns the items r may see, in sidebar order.
+func (reg *Registry) Links(r *http.Request) []Item {
+	signedIn := reg.SignedIn != nil && reg.SignedIn(r)
+	links := make([]Item, 0, len(reg.items))
+	for _, item := range reg.items {
+		if item.AuthOnly && !signedIn || item.GuestOnly && signedIn {
+			continue
+		}
+		links = append(links, item)
+	}
+	return links
+}
+
+// Routes registers a GET route on mux for every item.
+func (reg *Registry) Routes(mux *http.ServeMux) {
+	for _, item := range reg.items {
+		h := item.Handler
+		if item.AuthOnly && reg.Protect != nil {
+			h = reg.Protect(h)
+		}
+		pattern := item.Path
+		if pattern == "/" {
+			// "/" alone would match every path.
+			pattern = "/{$}"
+		}
+		mux.Handle("GET "+pattern, h)
+	}
+}
+
+var defaultRegistry atomic.Pointer[Registry]
+
+// SetDefault makes reg the registry used by the package level Links.
+func SetDefault(reg *Registry) {
+	defaultRegistry.Store(reg)
+}
+
+// Links returns the items r may see from the default registry, or nil if
+// none has been set.
+func Links(r *http.Request) []Item {
+	if reg := defaultRegistry.Load(); reg != nil {
+		return reg.Links(r)
+	}
+	return nil
+}
+
+// SignedIn reports whether r comes from a signed-in user, according to the
+// default registry. It is false if none has been set.
+func SignedIn(r *http.Request) bool {
+	reg := defaultRegistry.Load()
+	return reg != nil && reg.SignedIn != nil && reg.SignedIn(r)
+}
@@ -0,0 +1,39 @@
+package nav
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	reg := &Registry{SignedIn: func(r *http.Request) bool { return r.Header.Get("X-User") != "" }}
+	reg.Add(Item{Key: "login", Order: 50, GuestOnly: true})
+	reg.Add(Item{Key: "home", Order: 10})
+	reg.Add(Item{Key: "settings", Order: 40, AuthOnly: true})
+
+	tests := []struct {
+		name string
+		user string
+		want []string
+	}{
+		{"anonymous", "", []string{"home", "login"}},
+		{"signed in", "u1", []string{"home", "settings"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.user != "" {
+				r.Header.Set("X-User", tt.user)
+			}
+			var got []string
+			for _, item := range reg.Links(r) {
+				got = append(got, item.Key)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Links() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package progress
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"alexdunmow.com/internal/skills"
+)
+
+// ProgressCookie holds an anonymous visitor's unlocked skills. Keeping them
+// in the browser means visitors cost the server nothing, however many there
+// are; only signed-in users' progress is saved on the server.
+const ProgressCookie = "progress"
+
+// maxCookieValue keeps ProgressCookie under the 4KB browsers allow a cookie.
+const maxCookieValue = 4000
+
+// ErrCookieFull is returned when an anonymous visitor has unlocked more than
+// ProgressCookie can hold.
+var ErrCookieFull = errors.New("too many skills unlocked to remember without an account; log in to unlock more")
+
+// cookieStore is the Store for one anonymous visitor's request. It ignores
+// the owner, since the cookie only ever holds that visitor's progress.
+type cookieStore struct {
+	w        http.ResponseWriter
+	r        *http.Request
+	unlocked []string
+}
+
+// newCookieStore reads the visitor's progress from r's ProgressCookie. The
+// cookie is the visitor's own to edit, so it is only trusted as far as
+// naming skills that exist; anything else in it is dropped.
+func newCookieStore(w http.ResponseWriter, r *http.Request, graph *skills.Graph) *cookieStore {
+	s := &cookieStore{w: w, r: r}
+
+	c, err := r.Cookie(ProgressCookie)
+	if err != nil {
+		return s
+	}
+	b, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return s
+	}
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return s
+	}
+	for _, name := range names {
+		if _, err := graph.Get(name); err == nil {
+			s.unlocked = append(s.unlocked, name)
+		}
+	}
+	slices.Sort(s.unlocked)
+	s.unlocked = slices.Compact(s.unlocked)
+	return s
+}
+
+// Unlocked returns the visitor's unlocked skills, in alphabetical order.
+func (s *cookieStore) Unlocked(string) ([]string, error) {
+	return slices.Clone(s.unlocked), nil
+}
+
+// Add records name as unlocked and sets the updated cookie on the response.
+func (s *cookieStore) Add(_, name string) error {
+	i, found := slices.BinarySearch(s.unlocked, name)
+	if found {
+		return nil
+	}
+	unlocked := slices.Insert(slices.Clone(s.unlocked), i, name)
+
+	b, err := json.Marshal(unlocked)
+	if err != nil {
+		return err
+	}
+	value := base64.RawURLEncoding.EncodeToString(b)
+	if len(value) > maxCookieValue {
+		return ErrCookieFull
+	}
+
+	http.SetCookie(s.w, &http.Cookie{
+		Name:     ProgressCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		Secure:   s.r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	s.unlocked = unlocked
+	return nil
+}
@@ -0,0 +1,58 @@
+package progress
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"alexdunmow.com/internal/jsonfile"
+)
+
+// FileStore keeps progress in memory and persists it to a single JSON file.
+type FileStore struct {
+	path string
+
+	mu   sync.RWMutex
+	data map[string][]string
+}
+
+// NewFileStore opens the store at path, loading any progress already saved
+// there. A missing file is treated as an empty store.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path: path,
+		data: map[string][]string{},
+	}
+
+	if err := jsonfile.Load(path, &s.data); err != nil {
+		return nil, fmt.Errorf("loading progress: %w", err)
+	}
+	return s, nil
+}
+
+// Unlocked returns the skills owner has unlocked, in alphabetical order.
+func (s *FileStore) Unlocked(owner string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.data[owner]), nil
+}
+
+// Add records name as unlocked for owner and writes the store to disk.
+func (s *FileStore) Add(owner, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.data[owner]
+	i, found := slices.BinarySearch(prev, name)
+	if found {
+		return nil
+	}
+	s.data[owner] = slices.Insert(slices.Clone(prev), i, name)
+
+	if err := jsonfile.Save(s.path, s.data); err != nil {
+		s.data[owner] = prev
+		return err
+	}
+	return nil
+}
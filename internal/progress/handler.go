@@ -0,0 +1,75 @@
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"alexdunmow.com/internal/middleware"
+	"alexdunmow.com/internal/skills"
+)
+
+// Handler serves the progress API.
+type Handler struct {
+	Store Store
+	Graph *skills.Graph
+}
+
+type progressResponse struct {
+	Unlocked []string `json:"unlocked"`
+}
+
+// Show writes the skills the current user or visitor has unlocked.
+func (h *Handler) Show(w http.ResponseWriter, r *http.Request) {
+	store, owner := h.storeFor(w, r)
+	unlocked, err := store.Unlocked(owner)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, progressResponse{Unlocked: nonNil(unlocked)})
+}
+
+// Unlock unlocks the skill named by the {name} path value and writes the
+// resulting unlocked set. A skill with no unlocked parent, or one a visitor's
+// cookie has no room for, is a 409 Conflict.
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	store, owner := h.storeFor(w, r)
+	unlocked, err := Unlock(store, h.Graph, owner, r.PathValue("name"))
+	switch {
+	case errors.Is(err, skills.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrLocked), errors.Is(err, ErrCookieFull):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, progressResponse{Unlocked: nonNil(unlocked)})
+	}
+}
+
+// storeFor returns where r's progress is kept and its key there: the Store,
+// keyed by user, for signed-in users, or ProgressCookie for anyone else.
+func (h *Handler) storeFor(w http.ResponseWriter, r *http.Request) (Store, string) {
+	if user := middleware.CurrentUser(r); user != nil {
+		return h.Store, "user:" + user.ID
+	}
+	return newCookieStore(w, r, h.Graph), ""
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,156 @@
+package progress
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"alexdunmow.com/internal/auth"
+	"alexdunmow.com/internal/middleware"
+)
+
+// serve runs h's routes for a request, signed in as user unless it is nil.
+func serve(h *Handler, user *auth.User, method, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/skills/{name}/unlock", h.Unlock)
+	mux.HandleFunc("GET /api/progress", h.Show)
+	withUser := middleware.Adapt(func(ctx *middleware.CustomContext, w http.ResponseWriter, r *http.Request) error {
+		ctx.User = user
+		return nil
+	})
+
+	r := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	middleware.Chain(mux, middleware.Context, withUser).ServeHTTP(w, r)
+	return w
+}
+
+func decodeUnlocked(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	var body progressResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+	return body.Unlocked
+}
+
+func progressCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == ProgressCookie {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestAnonymousProgressStaysInTheCookie(t *testing.T) {
+	store := &memStore{data: map[string][]string{}}
+	h := &Handler{Store: store, Graph: testGraph()}
+
+	w := serve(h, nil, http.MethodPost, "/api/skills/go/unlock")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlocking go: status %d, body %s", w.Code, w.Body)
+	}
+	c := progressCookie(w)
+	if c == nil || !c.HttpOnly {
+		t.Fatalf("cookies = %v, want an HttpOnly %s cookie", w.Result().Cookies(), ProgressCookie)
+	}
+
+	w = serve(h, nil, http.MethodPost, "/api/skills/web/unlock", c)
+	if got := decodeUnlocked(t, w); !slices.Equal(got, []string{"go", "web"}) {
+		t.Errorf("unlocked = %v, want [go web]", got)
+	}
+	c = progressCookie(w)
+
+	w = serve(h, nil, http.MethodGet, "/api/progress", c)
+	if got := decodeUnlocked(t, w); !slices.Equal(got, []string{"go", "web"}) {
+		t.Errorf("progress = %v, want [go web]", got)
+	}
+	if store.writes != 0 || len(store.data) != 0 {
+		t.Errorf("store = %v after %d writes, want nothing saved for visitors", store.data, store.writes)
+	}
+}
+
+func TestAnonymousProgressCookieIsFiltered(t *testing.T) {
+	h := &Handler{Store: &memStore{data: map[string][]string{}}, Graph: testGraph()}
+	cookie := func(v string) *http.Cookie {
+		return &http.Cookie{Name: ProgressCookie, Value: v}
+	}
+	encode := func(names ...string) *http.Cookie {
+		b, _ := json.Marshal(names)
+		return cookie(base64.RawURLEncoding.EncodeToString(b))
+	}
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   []string
+	}{
+		{"unknown skills", encode("web", "nope", "go", "web"), []string{"go", "web"}},
+		{"not base64", cookie("!!!"), []string{}},
+		{"not json", cookie(base64.RawURLEncoding.EncodeToString([]byte("go"))), []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, nil, http.MethodGet, "/api/progress", tt.cookie)
+			if got := decodeUnlocked(t, w); !slices.Equal(got, tt.want) {
+				t.Errorf("progress = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymousProgressCookieFull(t *testing.T) {
+	w := httptest.NewRecorder()
+	s := &cookieStore{w: w, r: httptest.NewRequest(http.MethodPost, "/", nil)}
+	long := strings.Repeat("x", 100)
+	for i := 0; ; i++ {
+		err := s.Add("", fmt.Sprintf("%03d-%s", i, long))
+		if errors.Is(err, ErrCookieFull) {
+			break
+		}
+		if err != nil || i > 100 {
+			t.Fatalf("Add() after %d skills = %v, want ErrCookieFull eventually", i, err)
+		}
+	}
+	if c := progressCookie(w); c == nil || len(c.Value) > maxCookieValue {
+		t.Errorf("last cookie set = %v, want one within %d bytes", c, maxCookieValue)
+	}
+}
+
+func TestSignedInProgressIsStored(t *testing.T) {
+	store := &memStore{data: map[string][]string{}}
+	h := &Handler{Store: store, Graph: testGraph()}
+	user := &auth.User{ID: "u1"}
+
+	w := serve(h, user, http.MethodPost, "/api/skills/js/unlock")
+	if w.Code != http.StatusOK || progressCookie(w) != nil {
+		t.Fatalf("status %d, cookies %v, want 200 and no progress cookie", w.Code, w.Result().Cookies())
+	}
+	if got := store.data["user:u1"]; !slices.Equal(got, []string{"js"}) {
+		t.Errorf("stored = %v, want [js]", got)
+	}
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/api/skills/htmx/unlock", http.StatusConflict},
+		{"/api/skills/nope/unlock", http.StatusNotFound},
+		{"/api/skills/web/unlock", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serve(h, user, http.MethodPost, tt.target); w.Code != tt.status {
+			t.Errorf("POST %s: status %d, want %d", tt.target, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package progress records which skills each visitor has unlocked.
+package progress
+
+import (
+	"errors"
+	"fmt"
+
+	"alexdunmow.com/internal/skills"
+)
+
+// ErrLocked is returned by Unlock when none of a skill's parents are unlocked.
+var ErrLocked = errors.New("skill is locked")
+
+// Store keeps the set of unlocked skill names per owner. The FileStore holds
+// signed-in users' progress; anonymous visitors keep theirs in ProgressCookie.
+type Store interface {
+	Unlocked(owner string) ([]string, error)
+	Add(owner, name string) error
+}
+
+// CanUnlock reports whether name may be unlocked given the already unlocked
+// skills. Root skills can always be unlocked; any other skill needs at least
+// one unlocked parent. This mirrors canUnlock in static/ts/skillTree.ts.
+func CanUnlock(graph *skills.Graph, unlocked []string, name string) (bool, error) {
+	parents, err := graph.Parents(name)
+	if err != nil {
+		return false, err
+	}
+	if len(parents) == 0 {
+		return true, nil
+	}
+
+	set := make(map[string]bool, len(unlocked))
+	for _, n := range unlocked {
+		set[n] = true
+	}
+	for _, p := range parents {
+		if set[p.Name] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Unlock unlocks name for owner if the rules allow it, and returns the
+// owner's unlocked skills afterwards. Unlocking an unlocked skill is a no-op.
+func Unlock(store Store, graph *skills.Graph, owner, name string) ([]string, error) {
+	unlocked, err := store.Unlocked(owner)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range unlocked {
+		if n == name {
+			return unlocked, nil
+		}
+	}
+
+	ok, err := CanUnlock(graph, unlocked, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unlock one of %q's parents first", ErrLocked, name)
+	}
+
+	if err := store.Add(owner, name); err != nil {
+		return nil, err
+	}
+	return store.Unlocked(owner)
+}
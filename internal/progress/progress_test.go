@@ -0,0 +1,80 @@
+package progress
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"alexdunmow.com/internal/skills"
+)
+
+// testGraph has two roots, a skill with two parents and one with a single
+// parent:
+//
+//	go ─┬─ web ── htmx
+//	js ─┘
+func testGraph() *skills.Graph {
+	return skills.New(map[string]skills.Skill{
+		"go":   {Name: "go", Children: []string{"web"}, Love: 3},
+		"js":   {Name: "js", Children: []string{"web"}, Love: 3},
+		"web":  {Name: "web", Children: []string{"htmx"}, Love: 3},
+		"htmx": {Name: "htmx", Love: 3},
+	})
+}
+
+func TestCanUnlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		unlocked []string
+		want     bool
+		err      error
+	}{
+		{"go", nil, true, nil},
+		{"web", nil, false, nil},
+		{"web", []string{"js"}, true, nil},
+		{"web", []string{"go", "js"}, true, nil},
+		{"htmx", []string{"go", "js"}, false, nil},
+		{"htmx", []string{"web"}, true, nil},
+		{"nope", nil, false, skills.ErrNotFound},
+	}
+	g := testGraph()
+	for _, tt := range tests {
+		got, err := CanUnlock(g, tt.unlocked, tt.name)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("CanUnlock(%v, %q) = %v, %v, want %v, %v", tt.unlocked, tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// memStore is a Store that counts its writes.
+type memStore struct {
+	data   map[string][]string
+	writes int
+}
+
+func (s *memStore) Unlocked(owner string) ([]string, error) {
+	return slices.Clone(s.data[owner]), nil
+}
+
+func (s *memStore) Add(owner, name string) error {
+	s.writes++
+	s.data[owner] = append(s.data[owner], name)
+	slices.Sort(s.data[owner])
+	return nil
+}
+
+func TestUnlock(t *testing.T) {
+	store := &memStore{data: map[string][]string{"u": {"go"}}}
+	g := testGraph()
+
+	if _, err := Unlock(store, g, "u", "htmx"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Unlock(htmx) error = %v, want ErrLocked", err)
+	}
+	got, err := Unlock(store, g, "u", "web")
+	if err != nil || !slices.Equal(got, []string{"go", "web"}) {
+		t.Errorf("Unlock(web) = %v, %v, want [go web]", got, err)
+	}
+	if _, err := Unlock(store, g, "u", "web"); err != nil || store.writes != 1 {
+		t.Errorf("unlocking web again: error = %v, writes = %d, want no new write", err, store.writes)
+	}
+}
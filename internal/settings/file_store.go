@@ -0,0 +1,62 @@
+package settings
+
+import (
+	"fmt"
+	"sync"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/jsonfile"
+)
+
+// FileStore keeps settings in memory and persists them to a single JSON file.
+type FileStore struct {
+	path string
+
+	mu   sync.RWMutex
+	data map[string]components.UserSettings
+}
+
+// NewFileStore opens the store at path, loading any settings already saved there.
+// A missing file is treated as an empty store.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path: path,
+		data: map[string]components.UserSettings{},
+	}
+
+	if err := jsonfile.Load(path, &s.data); err != nil {
+		return nil, fmt.Errorf("loading settings: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the settings saved for user, or Defaults if there are none.
+func (s *FileStore) Get(user string) (components.UserSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, ok := s.data[user]
+	if !ok {
+		return Defaults, nil
+	}
+	return settings, nil
+}
+
+// Save stores settings for user and writes the whole store to disk.
+func (s *FileStore) Save(user string, settings components.UserSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, existed := s.data[user]
+	s.data[user] = settings
+
+	if err := jsonfile.Save(s.path, s.data); err != nil {
+		if existed {
+			s.data[user] = prev
+		} else {
+			delete(s.data, user)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package settings
+
+import (
+	"net/http"
+	"strings"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/layout"
+	"alexdunmow.com/internal/middleware"
+	"alexdunmow.com/internal/theme"
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+const savedFlash = "Settings saved."
+
+// Handler serves the settings page and its form submissions.
+type Handler struct {
+	Store  Store
+	Themes *theme.Registry
+}
+
+// Show renders the settings page for the current user.
+func (h *Handler) Show(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	settings, err := h.settings(r)
+	if err != nil {
+		return nil, err
+	}
+
+	flash := ""
+	if r.URL.Query().Get("saved") == "1" {
+		flash = savedFlash
+	}
+
+	return layout.Render(w, r, h.settingsPage(r, settings, nil, flash, nil)), nil
+}
+
+// Update validates and saves the posted settings. HTMX requests get the form
+// back with inline errors or a success flash; plain form posts are redirected
+// to the settings page once saved.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	settings := components.UserSettings{
+		Email:           strings.TrimSpace(r.FormValue("email")),
+		NotificationsOn: r.FormValue("notifications") == "on",
+		Theme:           r.FormValue("theme"),
+	}
+	htmx := r.Header.Get("HX-Request") == "true"
+	themes := h.Themes.Themes(userKey(r))
+
+	if errs := Validate(settings, themes); errs != nil {
+		if htmx {
+			// htmx does not swap 4xx responses by default, so the form is
+			// re-rendered with a 200 and the errors inline.
+			return components.SettingsForm(settings, errs, "", themes), nil
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return layout.Render(w, r, h.settingsPage(r, settings, errs, "", nil)), nil
+	}
+
+	if err := h.Store.Save(userKey(r), settings); err != nil {
+		return nil, err
+	}
+
+	if htmx {
+		// The theme may have changed, so bring the page and the sidebar's
+		// toggle along.
+		h.themeChanged(w, r, settings.Theme)
+		return g.Group{
+			components.SettingsForm(settings, nil, savedFlash, themes),
+			components.ThemeToggle(settings.Theme, Data("hx-swap-oob", "true")),
+		}, nil
+	}
+	http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+	return nil, nil
+}
+
+// settingsPage returns the settings page for r's user. editor is the custom
+// theme form, or nil for an empty one.
+func (h *Handler) settingsPage(r *http.Request, settings components.UserSettings, errs map[string]string, flash string, editor g.Node) layout.Page {
+	if editor == nil {
+		editor = components.ThemeEditor(newDraft(), nil, "")
+	}
+	owner := userKey(r)
+	return layout.Page{
+		Title:  "Settings",
+		Active: "settings",
+		Body:   components.Settings(settings, errs, flash, h.Themes.Themes(owner), h.Themes.Custom(owner), editor),
+	}
+}
+
+// settings returns the settings saved for r's user. Until they first save,
+// the email is the one on their account.
+func (h *Handler) settings(r *http.Request) (components.UserSettings, error) {
+	settings, err := h.Store.Get(userKey(r))
+	if err != nil {
+		return settings, err
+	}
+	if user := middleware.CurrentUser(r); user != nil && settings.Email == "" {
+		settings.Email = user.Email
+	}
+	return settings, nil
+}
+
+// userKey returns the store key for the request's user.
+func userKey(r *http.Request) string {
+	if user := middleware.CurrentUser(r); user != nil {
+		return user.ID
+	}
+	return DefaultUser
+}
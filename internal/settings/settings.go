@@ -0,0 +1,50 @@
+// Package settings stores and validates per-user preferences.
+package settings
+
+import (
+	"net/mail"
+	"slices"
+	"strings"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/theme"
+)
+
+// DefaultUser is the store key used for requests without a signed-in user.
+const DefaultUser = "default"
+
+// Defaults are returned for users who have never saved their settings. The
+// handler fills in the email from the user's account.
+var Defaults = components.UserSettings{
+	Email:           "",
+	NotificationsOn: true,
+	Theme:           "",
+}
+
+// Store loads and saves settings keyed by user.
+type Store interface {
+	Get(user string) (components.UserSettings, error)
+	Save(user string, s components.UserSettings) error
+}
+
+// Validate checks s, whose theme must be one of themes, and returns a message
+// per invalid field, or nil if s is valid.
+func Validate(s components.UserSettings, themes []theme.Theme) map[string]string {
+	errs := map[string]string{}
+
+	email := strings.TrimSpace(s.Email)
+	if email == "" {
+		errs["email"] = "Email is required."
+	} else if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		errs["email"] = "Enter a valid email address."
+	}
+
+	if s.Theme != "" && !slices.ContainsFunc(themes, func(t theme.Theme) bool { return t.Name == s.Theme }) {
+		errs["theme"] = "Choose a theme from the list."
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,225 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/layout"
+	"alexdunmow.com/internal/middleware"
+	"alexdunmow.com/internal/theme"
+	"alexdunmow.com/internal/view"
+	g "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+)
+
+// themeCookieAge is how long an anonymous visitor's theme is remembered.
+const themeCookieAge = 365 * 24 * time.Hour
+
+// LoadTheme is middleware that puts the visitor's theme in the request
+// context: the saved setting for signed-in users, the theme cookie for
+// everyone else. It must run after middleware.LoadUser.
+func (h *Handler) LoadTheme(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, owner := "", userKey(r)
+		if user := middleware.CurrentUser(r); user != nil {
+			settings, err := h.Store.Get(user.ID)
+			if err != nil {
+				// The page still works without the theme.
+				slog.WarnContext(r.Context(), "loading theme", "user_id", user.ID, "err", err)
+			}
+			name = settings.Theme
+		} else if c, err := r.Cookie(theme.Cookie); err == nil {
+			name = c.Value
+		}
+		if !h.Themes.Valid(owner, name) {
+			name = ""
+		}
+		sel := theme.Selection{Name: name, Stylesheet: h.Themes.Stylesheet(owner)}
+		next.ServeHTTP(w, r.WithContext(theme.NewContext(r.Context(), sel)))
+	})
+}
+
+// SetTheme saves the posted theme, "" meaning the browser's choice. HTMX
+// requests get the theme toggle back and a theme-changed event carrying the
+// new theme; plain form posts are sent back to the page they came from.
+func (h *Handler) SetTheme(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	name := r.FormValue("theme")
+	if name != "" && !h.Themes.Valid(userKey(r), name) {
+		return nil, view.StatusError(http.StatusBadRequest, fmt.Errorf("unknown theme %q", name))
+	}
+
+	if user := middleware.CurrentUser(r); user != nil {
+		settings, err := h.settings(r)
+		if err != nil {
+			return nil, err
+		}
+		settings.Theme = name
+		if err := h.Store.Save(user.ID, settings); err != nil {
+			return nil, err
+		}
+	} else {
+		setThemeCookie(w, r, name)
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.themeChanged(w, r, name)
+		return components.ThemeToggle(name), nil
+	}
+	back := r.Referer()
+	if back == "" {
+		back = "/"
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+	return nil, nil
+}
+
+// setThemeCookie remembers an anonymous visitor's theme, or forgets it when
+// name is "".
+func setThemeCookie(w http.ResponseWriter, r *http.Request, name string) {
+	c := &http.Cookie{
+		Name:     theme.Cookie,
+		Value:    name,
+		Path:     "/",
+		MaxAge:   int(themeCookieAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if name == "" {
+		c.MaxAge = -1
+	}
+	http.SetCookie(w, c)
+}
+
+// PreviewTheme renders the posted palette in the theme editor's preview.
+func (h *Handler) PreviewTheme(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	return components.ThemePreview(draftFromForm(r).Palette.Normalize()), nil
+}
+
+// ServeCSS writes the stylesheet defining the themes the visitor can choose.
+func (h *Handler) ServeCSS(w http.ResponseWriter, r *http.Request) {
+	h.Themes.ServeCSS(w, r, userKey(r))
+}
+
+// CreateTheme adds the posted custom theme and switches the user to it. HTMX
+// requests get an empty editor back, along with the user's themes, the
+// settings form and theme toggle updated out of band; plain form posts are
+// redirected to the settings page. Palettes failing the contrast checks are
+// rejected, as are themes past the user's MaxCustom.
+func (h *Handler) CreateTheme(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	draft := draftFromForm(r)
+	htmx := r.Header.Get("HX-Request") == "true"
+	owner := userKey(r)
+
+	errs, err := h.Themes.Add(owner, draft)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := h.settings(r)
+	if err != nil {
+		return nil, err
+	}
+	if errs != nil {
+		editor := components.ThemeEditor(draft, errs, "")
+		if htmx {
+			// As in Update, the errors are shown with a 200 so htmx swaps
+			// them in.
+			return editor, nil
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return layout.Render(w, r, h.settingsPage(r, settings, nil, "", editor)), nil
+	}
+
+	settings.Theme = draft.Name
+	if err := h.Store.Save(owner, settings); err != nil {
+		return nil, err
+	}
+
+	if htmx {
+		h.themeChanged(w, r, draft.Name)
+		return g.Group{
+			components.ThemeEditor(newDraft(), nil, fmt.Sprintf("Created %s and switched to it.", draft.Label)),
+			components.CustomThemes(h.Themes.Custom(owner), Data("hx-swap-oob", "true")),
+			components.SettingsForm(settings, nil, "", h.Themes.Themes(owner), Data("hx-swap-oob", "true")),
+			components.ThemeToggle(draft.Name, Data("hx-swap-oob", "true")),
+		}, nil
+	}
+	http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+	return nil, nil
+}
+
+// DeleteTheme deletes the user's custom theme named by the {name} path
+// value, moving the user back to the browser's theme if it was theirs. HTMX
+// requests get the user's remaining themes back, along with the settings
+// form and theme toggle updated out of band; plain form posts are redirected
+// to the settings page.
+func (h *Handler) DeleteTheme(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	owner := userKey(r)
+	err := h.Themes.Remove(owner, r.PathValue("name"))
+	if errors.Is(err, theme.ErrNotFound) {
+		return nil, view.StatusError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := h.settings(r)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Theme == r.PathValue("name") {
+		settings.Theme = ""
+		if err := h.Store.Save(owner, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.themeChanged(w, r, settings.Theme)
+		return g.Group{
+			components.CustomThemes(h.Themes.Custom(owner)),
+			components.SettingsForm(settings, nil, "", h.Themes.Themes(owner), Data("hx-swap-oob", "true")),
+			components.ThemeToggle(settings.Theme, Data("hx-swap-oob", "true")),
+		}, nil
+	}
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+	return nil, nil
+}
+
+// newDraft returns the theme the editor starts from.
+func newDraft() theme.Theme {
+	return theme.Theme{Palette: theme.Builtin[0].Palette}
+}
+
+// draftFromForm reads a theme from the editor's fields.
+func draftFromForm(r *http.Request) theme.Theme {
+	label := strings.TrimSpace(r.FormValue("label"))
+	return theme.Theme{
+		Name:  theme.Slug(label),
+		Label: label,
+		Palette: theme.Palette{
+			Primary:    r.FormValue("primary"),
+			Secondary:  r.FormValue("secondary"),
+			Accent:     r.FormValue("accent"),
+			Background: r.FormValue("background"),
+			Text:       r.FormValue("text"),
+		},
+	}
+}
+
+// themeChanged tells the page to switch its <body> to the named theme, which
+// boosted navigations would otherwise leave as it was, and to load the
+// stylesheet defining r's user's themes.
+func (h *Handler) themeChanged(w http.ResponseWriter, r *http.Request, name string) {
+	b, _ := json.Marshal(map[string]any{"theme-changed": map[string]string{
+		"theme":      name,
+		"stylesheet": h.Themes.Stylesheet(userKey(r)),
+	}})
+	w.Header().Set("HX-Trigger", string(b))
+}
@@ -0,0 +1,218 @@
+package skills
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Diagram sizes, in SVG user units.
+const (
+	NodeWidth   = 200
+	NodeHeight  = 56
+	NodeSpacing = 24
+	LayerHeight = 140
+	Margin      = 20
+)
+
+// Diagram is a layered layout of the graph, ready to draw. Each skill appears
+// once; a skill with several parents sits in the layer below its deepest
+// parent and has an edge from each of them.
+type Diagram struct {
+	Width, Height int
+	Nodes         []DiagramNode
+	Edges         []DiagramEdge
+}
+
+// DiagramNode is a skill placed on the diagram. X and Y are its centre.
+type DiagramNode struct {
+	Skill Skill
+	Layer int
+	X, Y  int
+}
+
+// DiagramEdge joins the bottom centre of a parent to the top centre of a child.
+type DiagramEdge struct {
+	From, To       string
+	X1, Y1, X2, Y2 int
+}
+
+// Layout positions every skill in layers, roots at the top. Within a layer,
+// skills are ordered to keep them near their parents and children, which
+// reduces edge crossings.
+func (g *Graph) Layout() *Diagram {
+	layers := g.layers()
+	orderLayers(g, layers)
+
+	widest := 0
+	for _, layer := range layers {
+		widest = max(widest, len(layer))
+	}
+	rowWidth := func(n int) int {
+		return n*NodeWidth + max(n-1, 0)*NodeSpacing
+	}
+
+	d := &Diagram{
+		Width:  rowWidth(widest) + 2*Margin,
+		Height: len(layers)*NodeHeight + max(len(layers)-1, 0)*(LayerHeight-NodeHeight) + 2*Margin,
+	}
+	pos := map[string]DiagramNode{}
+	for depth, layer := range layers {
+		// Centre narrower layers under the widest one.
+		left := Margin + (rowWidth(widest)-rowWidth(len(layer)))/2
+		for i, name := range layer {
+			n := DiagramNode{
+				Skill: g.skills[name],
+				Layer: depth,
+				X:     left + i*(NodeWidth+NodeSpacing) + NodeWidth/2,
+				Y:     Margin + depth*LayerHeight + NodeHeight/2,
+			}
+			pos[name] = n
+			d.Nodes = append(d.Nodes, n)
+		}
+	}
+
+	for _, layer := range layers {
+		for _, name := range layer {
+			from := pos[name]
+			for _, child := range g.skills[name].Children {
+				to, ok := pos[child]
+				if !ok {
+					continue
+				}
+				d.Edges = append(d.Edges, DiagramEdge{
+					From: name, To: child,
+					X1: from.X, Y1: from.Y + NodeHeight/2,
+					X2: to.X, Y2: to.Y - NodeHeight/2,
+				})
+			}
+		}
+	}
+	return d
+}
+
+// layers assigns each skill the length of the longest path to it from a root,
+// so every edge points downwards. Skills caught in a cycle, which have no such
+// length, go in one extra layer at the bottom.
+func (g *Graph) layers() [][]string {
+	indegree := map[string]int{}
+	for _, name := range g.Names() {
+		for _, child := range g.skills[name].Children {
+			if _, ok := g.skills[child]; ok {
+				indegree[child]++
+			}
+		}
+	}
+
+	depth := map[string]int{}
+	var queue []string
+	for _, root := range g.Roots() {
+		queue = append(queue, root.Name)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, child := range g.skills[name].Children {
+			if _, ok := g.skills[child]; !ok {
+				continue
+			}
+			depth[child] = max(depth[child], depth[name]+1)
+			indegree[child]--
+			if indegree[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	var layers [][]string
+	var stuck []string
+	for _, name := range g.Names() {
+		if indegree[name] > 0 {
+			stuck = append(stuck, name)
+			continue
+		}
+		for len(layers) <= depth[name] {
+			layers = append(layers, nil)
+		}
+		layers[depth[name]] = append(layers[depth[name]], name)
+	}
+	if len(stuck) > 0 {
+		layers = append(layers, stuck)
+	}
+	return layers
+}
+
+// orderLayers sorts each layer in place using the barycenter heuristic: a
+// skill's position is pulled towards the average position of its neighbours
+// in the layers above (sweeping down) or below (sweeping up). A few sweeps
+// settle most crossings.
+func orderLayers(g *Graph, layers [][]string) {
+	// Positions are fractions of the layer's width, so neighbours in layers
+	// of different sizes (edges can skip layers) are comparable.
+	pos := map[string]float64{}
+	reindex := func(layer []string) {
+		for i, name := range layer {
+			pos[name] = (float64(i) + 0.5) / float64(len(layer))
+		}
+	}
+
+	// Start from the order children are listed in the data, which is how the
+	// data's author grouped them.
+	seen := map[string]bool{}
+	order := map[string]int{}
+	var visit func(name string)
+	visit = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		order[name] = len(order)
+		for _, child := range g.skills[name].Children {
+			if _, ok := g.skills[child]; ok {
+				visit(child)
+			}
+		}
+	}
+	for _, root := range g.Roots() {
+		visit(root.Name)
+	}
+	for _, name := range g.Names() {
+		visit(name)
+	}
+	for _, layer := range layers {
+		slices.SortStableFunc(layer, func(a, b string) int {
+			return cmp.Compare(order[a], order[b])
+		})
+		reindex(layer)
+	}
+
+	sortBy := func(layer []string, neighbours func(string) []string) {
+		centre := map[string]float64{}
+		for _, name := range layer {
+			sum, n := 0.0, 0
+			for _, nb := range neighbours(name) {
+				if p, ok := pos[nb]; ok {
+					sum += p
+					n++
+				}
+			}
+			if n == 0 {
+				centre[name] = pos[name]
+			} else {
+				centre[name] = sum / float64(n)
+			}
+		}
+		slices.SortStableFunc(layer, func(a, b string) int {
+			return cmp.Compare(centre[a], centre[b])
+		})
+		reindex(layer)
+	}
+
+	for sweep := 0; sweep < 4; sweep++ {
+		for i := 1; i < len(layers); i++ {
+			sortBy(layers[i], func(name string) []string { return g.parents[name] })
+		}
+		for i := len(layers) - 2; i >= 0; i-- {
+			sortBy(layers[i], func(name string) []string { return g.skills[name].Children })
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package skills
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is a diagram language the graph can be exported to.
+type Format string
+
+const (
+	FormatDOT     Format = "dot"
+	FormatMermaid Format = "mermaid"
+)
+
+// Formats lists every supported export format.
+var Formats = []Format{FormatDOT, FormatMermaid}
+
+// ParseFormat returns the Format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q, want dot or mermaid", s)
+}
+
+// ContentType is the MIME type to serve an export in format f with.
+func (f Format) ContentType() string {
+	if f == FormatDOT {
+		return "text/vnd.graphviz; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// loveStyle is how nodes are drawn at each love level, from barely liked
+// (pale) to favourite (the site's accent purple with a heavy border).
+var loveStyle = map[int]struct {
+	fill, font string
+	border     int
+}{
+	1: {"#f8f8ff", "#333333", 1},
+	2: {"#e6e6fa", "#333333", 1},
+	3: {"#ccccff", "#333333", 2},
+	4: {"#b19cd9", "#1a1a1a", 2},
+	5: {"#9370db", "#ffffff", 3},
+}
+
+func styleFor(love int) (fill, font string, border int) {
+	s, ok := loveStyle[love]
+	if !ok {
+		s = loveStyle[MinLove]
+	}
+	return s.fill, s.font, s.border
+}
+
+// Export writes the whole graph to w in format f. Nodes are styled by love
+// level and listed alphabetically so the output diffs cleanly.
+func (g *Graph) Export(w io.Writer, f Format) error {
+	bw := bufio.NewWriter(w)
+	switch f {
+	case FormatDOT:
+		g.writeDOT(bw)
+	case FormatMermaid:
+		g.writeMermaid(bw)
+	default:
+		return fmt.Errorf("unknown export format %q", f)
+	}
+	return bw.Flush()
+}
+
+func (g *Graph) writeDOT(w *bufio.Writer) {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+	quote := func(s string) string {
+		return `"` + escape(s) + `"`
+	}
+
+	fmt.Fprintln(w, "digraph skills {")
+	fmt.Fprintln(w, `  rankdir="TB";`)
+	fmt.Fprintln(w, `  node [shape="box", style="rounded,filled", fontname="Helvetica"];`)
+	fmt.Fprintln(w, `  edge [color="#999999"];`)
+	fmt.Fprintln(w)
+
+	names := g.Names()
+	for _, name := range names {
+		skill := g.skills[name]
+		fill, font, border := styleFor(skill.Love)
+		// \n inside a DOT string is a line break in the rendered label.
+		label := fmt.Sprintf(`"%s %s\nlove %d"`, escape(skill.Icon), escape(skill.Name), skill.Love)
+		fmt.Fprintf(w, "  %s [label=%s, fillcolor=%q, fontcolor=%q, penwidth=%d];\n",
+			quote(name), label, fill, font, border)
+	}
+	fmt.Fprintln(w)
+
+	for _, name := range names {
+		for _, child := range g.skills[name].Children {
+			fmt.Fprintf(w, "  %s -> %s;\n", quote(name), quote(child))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func (g *Graph) writeMermaid(w *bufio.Writer) {
+	// Mermaid node IDs can't contain spaces or punctuation, so each skill
+	// gets a generated ID and its name goes in the label.
+	names := g.Names()
+	ids := make(map[string]string, len(names))
+	for i, name := range names {
+		ids[name] = fmt.Sprintf("s%d", i)
+	}
+	id := func(name string) string {
+		if id, ok := ids[name]; ok {
+			return id
+		}
+		// A dangling child still gets a node, so the edge is visible.
+		ids[name] = fmt.Sprintf("s%d", len(ids))
+		return ids[name]
+	}
+	escape := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace
+
+	fmt.Fprintln(w, "flowchart TD")
+	for love := MinLove; love <= MaxLove; love++ {
+		fill, font, border := styleFor(love)
+		fmt.Fprintf(w, "  classDef love%d fill:%s,color:%s,stroke:#333333,stroke-width:%dpx\n", love, fill, font, border)
+	}
+
+	byLove := map[int][]string{}
+	for _, name := range names {
+		skill := g.skills[name]
+		fmt.Fprintf(w, "  %s[\"%s %s\"]\n", id(name), escape(skill.Icon), escape(skill.Name))
+		love := min(max(skill.Love, MinLove), MaxLove)
+		byLove[love] = append(byLove[love], id(name))
+	}
+
+	for _, name := range names {
+		for _, child := range g.skills[name].Children {
+			fmt.Fprintf(w, "  %s --> %s\n", id(name), id(child))
+		}
+	}
+
+	for love := MinLove; love <= MaxLove; love++ {
+		if len(byLove[love]) > 0 {
+			fmt.Fprintf(w, "  class %s love%d\n", strings.Join(byLove[love], ","), love)
+		}
+	}
+}
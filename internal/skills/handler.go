@@ -0,0 +1,70 @@
+package skills
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Handler serves the skill graph as a JSON API.
+type Handler struct {
+	Graph *Graph
+}
+
+// List writes every skill keyed by name, in the same shape as skills_tree.json.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.Graph.All())
+}
+
+// Show writes the skill named by the {name} path value.
+func (h *Handler) Show(w http.ResponseWriter, r *http.Request) {
+	skill, err := h.Graph.Get(r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, skill)
+}
+
+// Children writes the direct children of the skill named by the {name} path value.
+func (h *Handler) Children(w http.ResponseWriter, r *http.Request) {
+	children, err := h.Graph.Children(r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, children)
+}
+
+// Export writes the whole graph as a diagram in the format named by the
+// "format" query parameter, dot (the default) or mermaid.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = string(FormatDOT)
+	}
+	format, err := ParseFormat(name)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if err := h.Graph.Export(w, format); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,339 @@
+// Package skills loads the skill graph shared by the web server and the
+// skilltree command.
+package skills
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// ErrNotFound is returned when a skill name isn't in the graph.
+var ErrNotFound = errors.New("skill not found")
+
+// ErrNoPath is returned by Path when one skill can't be reached from another.
+var ErrNoPath = errors.New("no path")
+
+// Skill represents a node in our skills tree
+type Skill struct {
+	Name     string   `json:"name"`
+	Children []string `json:"children"`
+	Love     int      `json:"love"`
+	Icon     string   `json:"icon"`
+}
+
+// Graph is a read-only skill graph keyed by skill name. A skill may have
+// several parents, so the graph is a DAG rather than a tree.
+type Graph struct {
+	skills  map[string]Skill
+	parents map[string][]string
+}
+
+// Load parses a skill graph from JSON shaped like skills_tree.json.
+func Load(r io.Reader) (*Graph, error) {
+	var skills map[string]Skill
+	if err := json.NewDecoder(r).Decode(&skills); err != nil {
+		return nil, fmt.Errorf("parsing skills: %w", err)
+	}
+	return New(skills), nil
+}
+
+// LoadFile parses the skill graph in the file at path.
+func LoadFile(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading skills file: %w", err)
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// New builds a graph from skills keyed by name.
+func New(skills map[string]Skill) *Graph {
+	g := &Graph{
+		skills:  skills,
+		parents: map[string][]string{},
+	}
+	for _, name := range g.Names() {
+		for _, child := range skills[name].Children {
+			g.parents[child] = append(g.parents[child], name)
+		}
+	}
+	return g
+}
+
+// All returns every skill keyed by name.
+func (g *Graph) All() map[string]Skill {
+	return g.skills
+}
+
+// Names returns every skill name in alphabetical order.
+func (g *Graph) Names() []string {
+	names := make([]string, 0, len(g.skills))
+	for name := range g.skills {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Roots returns the skills that have no parents, in alphabetical order.
+func (g *Graph) Roots() []Skill {
+	var roots []Skill
+	for _, name := range g.Names() {
+		if len(g.parents[name]) == 0 {
+			roots = append(roots, g.skills[name])
+		}
+	}
+	return roots
+}
+
+// Get returns the skill with the given name.
+func (g *Graph) Get(name string) (Skill, error) {
+	skill, ok := g.skills[name]
+	if !ok {
+		return Skill{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return skill, nil
+}
+
+// Children returns the direct children of the named skill, in the order they
+// are listed in the data.
+func (g *Graph) Children(name string) ([]Skill, error) {
+	skill, err := g.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]Skill, 0, len(skill.Children))
+	for _, childName := range skill.Children {
+		child, err := g.Get(childName)
+		if err != nil {
+			return nil, fmt.Errorf("child of %q: %w", name, err)
+		}
+		children = append(children, child)
+	}
+	return children, nil
+}
+
+// Parents returns the skills that list the named skill as a child, in
+// alphabetical order.
+func (g *Graph) Parents(name string) ([]Skill, error) {
+	if _, err := g.Get(name); err != nil {
+		return nil, err
+	}
+
+	parents := make([]Skill, 0, len(g.parents[name]))
+	for _, parentName := range g.parents[name] {
+		parents = append(parents, g.skills[parentName])
+	}
+	return parents, nil
+}
+
+// Ancestors returns every skill the named skill can be reached from, nearest
+// first. Each skill appears once even if it is reachable by several paths.
+func (g *Graph) Ancestors(name string) ([]Skill, error) {
+	if _, err := g.Get(name); err != nil {
+		return nil, err
+	}
+	return g.walk(name, func(n string) ([]string, error) {
+		return g.parents[n], nil
+	})
+}
+
+// Descendants returns every skill reachable from the named skill, nearest
+// first. Each skill appears once even if it is reachable by several paths.
+func (g *Graph) Descendants(name string) ([]Skill, error) {
+	if _, err := g.Get(name); err != nil {
+		return nil, err
+	}
+	return g.walk(name, func(n string) ([]string, error) {
+		skill, err := g.Get(n)
+		if err != nil {
+			return nil, err
+		}
+		return skill.Children, nil
+	})
+}
+
+// SortedByLove returns every skill ordered by love level, highest first, with
+// ties broken alphabetically.
+func (g *Graph) SortedByLove() []Skill {
+	sorted := make([]Skill, 0, len(g.skills))
+	for _, name := range g.Names() {
+		sorted = append(sorted, g.skills[name])
+	}
+	slices.SortStableFunc(sorted, func(a, b Skill) int {
+		return cmp.Compare(b.Love, a.Love)
+	})
+	return sorted
+}
+
+// walk does a breadth-first search from start, not including start itself.
+// The visited set also keeps it from looping forever on cyclic data.
+func (g *Graph) walk(start string, next func(name string) ([]string, error)) ([]Skill, error) {
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	var out []Skill
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		neighbours, err := next(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range neighbours {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+
+			skill, err := g.Get(n)
+			if err != nil {
+				return nil, fmt.Errorf("reached from %q: %w", name, err)
+			}
+			out = append(out, skill)
+			queue = append(queue, n)
+		}
+	}
+	return out, nil
+}
+
+// Path returns the shortest chain of skills leading from one skill down to
+// another, including both ends, or ErrNoPath if to isn't a descendant of from.
+func (g *Graph) Path(from, to string) ([]Skill, error) {
+	if _, err := g.Get(from); err != nil {
+		return nil, err
+	}
+	if _, err := g.Get(to); err != nil {
+		return nil, err
+	}
+
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if name == to {
+			var path []Skill
+			for n := to; n != ""; n = prev[n] {
+				path = append(path, g.skills[n])
+			}
+			slices.Reverse(path)
+			return path, nil
+		}
+
+		for _, child := range g.skills[name].Children {
+			if _, seen := prev[child]; seen {
+				continue
+			}
+			if _, ok := g.skills[child]; !ok {
+				continue
+			}
+			prev[child] = name
+			queue = append(queue, child)
+		}
+	}
+	return nil, fmt.Errorf("%w from %q to %q", ErrNoPath, from, to)
+}
+
+// Paths returns up to limit paths leading from one skill down to another,
+// including both ends, shortest first. The search only follows skills that can
+// still reach to, nearest first, and stops once it has limit paths, so dense
+// graphs don't make it enumerate every path. The first path is always a
+// shortest one.
+func (g *Graph) Paths(from, to string, limit int) ([][]Skill, error) {
+	if _, err := g.Get(from); err != nil {
+		return nil, err
+	}
+	if _, err := g.Get(to); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	// dist is how many steps each skill that can reach to is from it.
+	dist := map[string]int{to: 0}
+	queue := []string{to}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, parent := range g.parents[name] {
+			if _, seen := dist[parent]; !seen {
+				dist[parent] = dist[name] + 1
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	var paths [][]Skill
+	var stack []Skill
+	onPath := map[string]bool{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		if len(paths) >= limit || onPath[name] {
+			return
+		}
+		if _, ok := dist[name]; !ok {
+			return
+		}
+
+		stack = append(stack, g.skills[name])
+		onPath[name] = true
+		if name == to {
+			paths = append(paths, slices.Clone(stack))
+		} else {
+			children := slices.Clone(g.skills[name].Children)
+			slices.SortStableFunc(children, func(a, b string) int {
+				return cmp.Compare(dist[a], dist[b])
+			})
+			for _, child := range children {
+				visit(child)
+			}
+		}
+		onPath[name] = false
+		stack = stack[:len(stack)-1]
+	}
+	visit(from)
+
+	slices.SortStableFunc(paths, func(a, b []Skill) int {
+		return cmp.Compare(len(a), len(b))
+	})
+	return paths, nil
+}
+
+// Siblings returns the other children of the named skill's parents, in
+// alphabetical order.
+func (g *Graph) Siblings(name string) ([]Skill, error) {
+	if _, err := g.Get(name); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{name: true}
+	var siblings []Skill
+	for _, parent := range g.parents[name] {
+		for _, child := range g.skills[parent].Children {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			if skill, ok := g.skills[child]; ok {
+				siblings = append(siblings, skill)
+			}
+		}
+	}
+	slices.SortFunc(siblings, func(a, b Skill) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return siblings, nil
+}
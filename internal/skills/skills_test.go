@@ -0,0 +1,179 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testGraph is a small DAG: a diamond under root, reached from root2 too,
+// plus e, which stands alone.
+//
+//	root ─┬─ a ─┬─ c ── d
+//	      └─ b ─┘
+//	root2 ── b
+//	e
+func testGraph() *Graph {
+	skill := func(name string, children ...string) Skill {
+		return Skill{Name: name, Children: children, Love: 3}
+	}
+	return New(map[string]Skill{
+		"root":  skill("root", "a", "b"),
+		"root2": skill("root2", "b"),
+		"a":     skill("a", "c"),
+		"b":     skill("b", "c"),
+		"c":     skill("c", "d"),
+		"d":     skill("d"),
+		"e":     skill("e"),
+	})
+}
+
+func names(skills []Skill) []string {
+	out := make([]string, len(skills))
+	for i, s := range skills {
+		out[i] = s.Name
+	}
+	return out
+}
+
+func TestRoots(t *testing.T) {
+	want := []string{"e", "root", "root2"}
+	if got := names(testGraph().Roots()); !slices.Equal(got, want) {
+		t.Errorf("Roots() = %v, want %v", got, want)
+	}
+}
+
+func TestAncestorsAndDescendants(t *testing.T) {
+	g := testGraph()
+	tests := []struct {
+		method string
+		walk   func(string) ([]Skill, error)
+		name   string
+		want   []string
+		err    error
+	}{
+		{"Ancestors", g.Ancestors, "d", []string{"c", "a", "b", "root", "root2"}, nil},
+		{"Ancestors", g.Ancestors, "b", []string{"root", "root2"}, nil},
+		{"Ancestors", g.Ancestors, "root", nil, nil},
+		{"Ancestors", g.Ancestors, "nope", nil, ErrNotFound},
+		{"Descendants", g.Descendants, "root", []string{"a", "b", "c", "d"}, nil},
+		{"Descendants", g.Descendants, "root2", []string{"b", "c", "d"}, nil},
+		{"Descendants", g.Descendants, "e", nil, nil},
+		{"Descendants", g.Descendants, "nope", nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+"/"+tt.name, func(t *testing.T) {
+			got, err := tt.walk(tt.name)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("%s(%q) error = %v, want %v", tt.method, tt.name, err, tt.err)
+			}
+			if !slices.Equal(names(got), tt.want) {
+				t.Errorf("%s(%q) = %v, want %v", tt.method, tt.name, names(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     []string
+		err      error
+	}{
+		{"root", "d", []string{"root", "a", "c", "d"}, nil},
+		{"root2", "d", []string{"root2", "b", "c", "d"}, nil},
+		{"root", "root", []string{"root"}, nil},
+		{"a", "b", nil, ErrNoPath},
+		{"d", "root", nil, ErrNoPath},
+		{"nope", "d", nil, ErrNotFound},
+		{"root", "nope", nil, ErrNotFound},
+	}
+	g := testGraph()
+	for _, tt := range tests {
+		got, err := g.Path(tt.from, tt.to)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Path(%q, %q) error = %v, want %v", tt.from, tt.to, err, tt.err)
+			continue
+		}
+		if !slices.Equal(names(got), tt.want) {
+			t.Errorf("Path(%q, %q) = %v, want %v", tt.from, tt.to, names(got), tt.want)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	tests := []struct {
+		from, to string
+		limit    int
+		want     [][]string
+		err      error
+	}{
+		{"root", "d", 10, [][]string{{"root", "a", "c", "d"}, {"root", "b", "c", "d"}}, nil},
+		{"root", "d", 1, [][]string{{"root", "a", "c", "d"}}, nil},
+		{"root", "d", 0, nil, nil},
+		{"root2", "d", 10, [][]string{{"root2", "b", "c", "d"}}, nil},
+		{"a", "b", 10, nil, nil},
+		{"e", "e", 10, [][]string{{"e"}}, nil},
+		{"nope", "d", 10, nil, ErrNotFound},
+	}
+	g := testGraph()
+	for _, tt := range tests {
+		got, err := g.Paths(tt.from, tt.to, tt.limit)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Paths(%q, %q, %d) error = %v, want %v", tt.from, tt.to, tt.limit, err, tt.err)
+			continue
+		}
+		gotNames := make([][]string, len(got))
+		for i, p := range got {
+			gotNames[i] = names(p)
+		}
+		if !slices.EqualFunc(gotNames, tt.want, slices.Equal) {
+			t.Errorf("Paths(%q, %q, %d) = %v, want %v", tt.from, tt.to, tt.limit, gotNames, tt.want)
+		}
+	}
+}
+
+// TestPathsDense checks that Paths stops at limit on a graph with far too
+// many paths to enumerate, and still puts a shortest path first.
+func TestPathsDense(t *testing.T) {
+	const layers, width = 30, 3 // 3^30 paths through the layers
+	skills := map[string]Skill{}
+	layer := func(i int) []string {
+		var out []string
+		for j := range width {
+			out = append(out, fmt.Sprintf("%d-%d", i, j))
+		}
+		return out
+	}
+	skills["start"] = Skill{Name: "start", Children: append(layer(0), "end")}
+	for i := range layers {
+		next := layer(i + 1)
+		if i == layers-1 {
+			next = []string{"end"}
+		}
+		for _, name := range layer(i) {
+			skills[name] = Skill{Name: name, Children: next}
+		}
+	}
+	skills["end"] = Skill{Name: "end"}
+	g := New(skills)
+
+	done := make(chan [][]Skill)
+	go func() {
+		paths, _ := g.Paths("start", "end", 5)
+		done <- paths
+	}()
+	select {
+	case paths := <-done:
+		if len(paths) != 5 {
+			t.Fatalf("Paths() returned %d paths, want 5", len(paths))
+		}
+		if got := names(paths[0]); !slices.Equal(got, []string{"start", "end"}) {
+			t.Errorf("first path = %v, want the shortcut [start end]", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Paths() didn't stop at its limit")
+	}
+}
@@ -0,0 +1,203 @@
+package skills
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MinLove and MaxLove bound a skill's love level.
+const (
+	MinLove = 1
+	MaxLove = 5
+)
+
+// Problem is a single issue found by Validate.
+type Problem struct {
+	Line    int    // 1-based line of the skill's key, or of a syntax error
+	Key     string // the skill's key in the top-level object
+	Message string
+}
+
+// String formats the problem as it would follow a "file:line: " prefix.
+func (p Problem) String() string {
+	if p.Key == "" {
+		return p.Message
+	}
+	return fmt.Sprintf("%q: %s", p.Key, p.Message)
+}
+
+// Validate checks skill data shaped like skills_tree.json and returns every
+// problem it finds, in file order. It reports keys that are defined twice,
+// unknown fields, "name" fields that differ from their key, love levels
+// outside MinLove–MaxLove, children that aren't skills, and cycles.
+func Validate(data []byte) []Problem {
+	entries, problems := decodeEntries(data)
+	if entries == nil {
+		return problems
+	}
+
+	lines := map[string]int{}
+	skills := map[string]Skill{}
+	for i, e := range entries {
+		if prev, ok := lines[e.key]; ok {
+			problems = append(problems, Problem{e.line, e.key, fmt.Sprintf("defined again (first defined on line %d); the later definition wins", prev)})
+		} else {
+			lines[e.key] = e.line
+		}
+
+		if err := json.Unmarshal(e.raw, &entries[i].skill); err != nil {
+			problems = append(problems, Problem{e.line, e.key, fmt.Sprintf("invalid skill: %v", err)})
+			continue
+		}
+		entries[i].ok = true
+		skills[e.key] = entries[i].skill
+
+		// Decode again, strictly, to catch misspelt field names that the
+		// lenient pass above silently ignores.
+		strict := json.NewDecoder(bytes.NewReader(e.raw))
+		strict.DisallowUnknownFields()
+		if err := strict.Decode(&Skill{}); err != nil {
+			problems = append(problems, Problem{e.line, e.key, err.Error()})
+		}
+	}
+
+	for _, e := range entries {
+		if !e.ok {
+			continue
+		}
+		skill := e.skill
+
+		if skill.Name != e.key {
+			problems = append(problems, Problem{e.line, e.key, fmt.Sprintf("name %q does not match its key", skill.Name)})
+		}
+		if skill.Love < MinLove || skill.Love > MaxLove {
+			problems = append(problems, Problem{e.line, e.key, fmt.Sprintf("love %d is outside %d–%d", skill.Love, MinLove, MaxLove)})
+		}
+		seen := map[string]bool{}
+		for _, child := range skill.Children {
+			if seen[child] {
+				problems = append(problems, Problem{e.line, e.key, fmt.Sprintf("child %q is listed more than once", child)})
+				continue
+			}
+			seen[child] = true
+			if _, ok := skills[child]; !ok {
+				problems = append(problems, Problem{e.line, e.key, fmt.Sprintf("child %q is not a skill", child)})
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(skills) {
+		problems = append(problems, Problem{lines[cycle[0]], cycle[0], "cycle: " + strings.Join(cycle, " -> ")})
+	}
+
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		return a.Line - b.Line
+	})
+	return problems
+}
+
+type entry struct {
+	key  string
+	line int
+	raw  json.RawMessage
+
+	skill Skill
+	ok    bool // skill was decoded
+}
+
+// decodeEntries reads the top-level object one member at a time so each key's
+// line number is known and duplicate keys aren't silently merged. A nil slice
+// means the data couldn't be read at all.
+func decodeEntries(data []byte) ([]entry, []Problem) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	syntaxProblem := func(err error) []Problem {
+		if se, ok := err.(*json.SyntaxError); ok {
+			return []Problem{{Line: lineAt(se.Offset), Message: se.Error()}}
+		}
+		return []Problem{{Line: lineAt(dec.InputOffset()), Message: err.Error()}}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, syntaxProblem(err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, []Problem{{Line: 1, Message: "top level must be an object of skills keyed by name"}}
+	}
+
+	entries := []entry{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, syntaxProblem(err)
+		}
+		line := lineAt(dec.InputOffset())
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, syntaxProblem(err)
+		}
+		entries = append(entries, entry{key: tok.(string), line: line, raw: raw})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, syntaxProblem(err)
+	}
+	return entries, nil
+}
+
+// findCycles returns each cycle among skills once, as the list of names along
+// it with the first name repeated at the end.
+func findCycles(skills map[string]Skill) [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var cycles [][]string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = inProgress
+		stack = append(stack, name)
+
+		// A child listed twice would otherwise report the same cycle twice.
+		seen := map[string]bool{}
+		for _, child := range skills[name].Children {
+			if _, ok := skills[child]; !ok || seen[child] {
+				continue
+			}
+			seen[child] = true
+			switch state[child] {
+			case unvisited:
+				visit(child)
+			case inProgress:
+				start := slices.Index(stack, child)
+				cycle := append(slices.Clone(stack[start:]), child)
+				cycles = append(cycles, cycle)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+
+	names := make([]string, 0, len(skills))
+	for name := range skills {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,112 @@
+package skills
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// want are the problems as "line: message".
+		want []string
+	}{
+		{
+			name: "valid",
+			data: `{
+  "a": {"name": "a", "children": ["b"], "love": 5, "icon": ""},
+  "b": {"name": "b", "children": [], "love": 1, "icon": ""}
+}`,
+			want: nil,
+		},
+		{
+			name: "duplicate key",
+			data: `{
+  "a": {"name": "a", "children": [], "love": 3, "icon": ""},
+  "a": {"name": "a", "children": [], "love": 4, "icon": ""}
+}`,
+			want: []string{`3: "a": defined again (first defined on line 2); the later definition wins`},
+		},
+		{
+			name: "missing child",
+			data: `{
+  "a": {"name": "a", "children": ["b"], "love": 3, "icon": ""}
+}`,
+			want: []string{`2: "a": child "b" is not a skill`},
+		},
+		{
+			name: "child listed twice",
+			data: `{
+  "a": {"name": "a", "children": ["b", "b"], "love": 3, "icon": ""},
+  "b": {"name": "b", "children": [], "love": 3, "icon": ""}
+}`,
+			want: []string{`2: "a": child "b" is listed more than once`},
+		},
+		{
+			name: "cycle",
+			data: `{
+  "a": {"name": "a", "children": ["b"], "love": 3, "icon": ""},
+  "b": {"name": "b", "children": ["c"], "love": 3, "icon": ""},
+  "c": {"name": "c", "children": ["a"], "love": 3, "icon": ""}
+}`,
+			want: []string{`2: "a": cycle: a -> b -> c -> a`},
+		},
+		{
+			name: "self cycle",
+			data: `{
+  "a": {"name": "a", "children": ["a"], "love": 3, "icon": ""}
+}`,
+			want: []string{`2: "a": cycle: a -> a`},
+		},
+		{
+			name: "name differs from key",
+			data: `{
+  "a": {"name": "A", "children": [], "love": 3, "icon": ""}
+}`,
+			want: []string{`2: "a": name "A" does not match its key`},
+		},
+		{
+			name: "love out of range",
+			data: `{
+  "a": {"name": "a", "children": [], "love": 0, "icon": ""},
+  "b": {"name": "b", "children": [], "love": 6, "icon": ""}
+}`,
+			want: []string{
+				`2: "a": love 0 is outside 1–5`,
+				`3: "b": love 6 is outside 1–5`,
+			},
+		},
+		{
+			name: "unknown field",
+			data: `{
+  "a": {"name": "a", "childs": [], "love": 3, "icon": ""}
+}`,
+			want: []string{`2: "a": json: unknown field "childs"`},
+		},
+		{
+			name: "not an object",
+			data: `[]`,
+			want: []string{`1: top level must be an object of skills keyed by name`},
+		},
+		{
+			name: "syntax error",
+			data: `{
+  "a": {"name": "a",,}
+}`,
+			want: []string{`2: invalid character ',' looking for beginning of object key string`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range Validate([]byte(tt.data)) {
+				got = append(got, fmt.Sprintf("%d: %s", p.Line, p))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package theme
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Palette is the set of colours a theme gives the site, each a hex colour
+// such as "#9370db".
+type Palette struct {
+	// Primary is the sidebar background and the text on accent buttons.
+	Primary string `json:"primary"`
+	// Secondary highlights the active link and fills inputs.
+	Secondary string `json:"secondary"`
+	// Accent colours headings and buttons.
+	Accent     string `json:"accent"`
+	Background string `json:"background"`
+	Text       string `json:"text"`
+}
+
+// Colors lists the palette's colours by field name, in the order they are
+// shown and written out as CSS variables.
+func (p Palette) Colors() []Color {
+	return []Color{
+		{"primary", "Primary", p.Primary},
+		{"secondary", "Secondary", p.Secondary},
+		{"accent", "Accent", p.Accent},
+		{"background", "Background", p.Background},
+		{"text", "Text", p.Text},
+	}
+}
+
+// Color is one colour of a palette.
+type Color struct {
+	// Field is the form field and the --color-<Field> CSS variable.
+	Field string
+	Label string
+	Value string
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ValidColor reports whether c is a hex colour such as "#9370db".
+func ValidColor(c string) bool {
+	return hexColor.MatchString(c)
+}
+
+// Normalize returns p with its colours lower-cased and stripped of spaces.
+func (p Palette) Normalize() Palette {
+	norm := func(c string) string { return strings.ToLower(strings.TrimSpace(c)) }
+	return Palette{
+		Primary:    norm(p.Primary),
+		Secondary:  norm(p.Secondary),
+		Accent:     norm(p.Accent),
+		Background: norm(p.Background),
+		Text:       norm(p.Text),
+	}
+}
+
+// Check is one pair of colours that appear on top of each other, and the
+// contrast ratio they need to stay readable.
+type Check struct {
+	// Field is the colour blamed when the check fails.
+	Field string
+	// Label describes where the pair appears.
+	Label string
+	// Ratio is the pair's contrast ratio, from 1 to 21.
+	Ratio float64
+	// Min is the lowest acceptable Ratio: 4.5 for body text and 3 for
+	// headings and buttons, as in WCAG 2 level AA.
+	Min float64
+}
+
+// OK reports whether the pair is readable.
+func (c Check) OK() bool {
+	return c.Ratio >= c.Min
+}
+
+// Checks measures the contrast of every pair of colours the site puts on top
+// of each other. It returns nil if any colour isn't a hex colour.
+func (p Palette) Checks() []Check {
+	if len(p.colorErrors()) > 0 {
+		return nil
+	}
+	pairs := []struct {
+		field, label string
+		fg, bg       string
+		min          float64
+	}{
+		{"text", "Text on the background", p.Text, p.Background, 4.5},
+		{"text", "Text on the sidebar", p.Text, p.Primary, 4.5},
+		{"text", "Text on inputs and the active link", p.Text, p.Secondary, 4.5},
+		{"accent", "Headings on the background", p.Accent, p.Background, 3},
+		{"primary", "Button text on the accent", p.Primary, p.Accent, 3},
+	}
+	checks := make([]Check, len(pairs))
+	for i, pair := range pairs {
+		checks[i] = Check{
+			Field: pair.field,
+			Label: pair.label,
+			Ratio: contrast(pair.fg, pair.bg),
+			Min:   pair.min,
+		}
+	}
+	return checks
+}
+
+// Validate returns a message per colour that is malformed or part of an
+// unreadable pair, or nil if the palette is fine.
+func (p Palette) Validate() map[string]string {
+	errs := p.colorErrors()
+	if len(errs) > 0 {
+		return errs
+	}
+	for _, c := range p.Checks() {
+		if c.OK() || errs[c.Field] != "" {
+			continue
+		}
+		errs[c.Field] = fmt.Sprintf("%s has a contrast ratio of %.2f:1; it needs at least %g:1.", c.Label, c.Ratio, c.Min)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (p Palette) colorErrors() map[string]string {
+	errs := map[string]string{}
+	for _, c := range p.Colors() {
+		if !ValidColor(c.Value) {
+			errs[c.Field] = c.Label + " must be a colour such as #9370db."
+		}
+	}
+	return errs
+}
+
+// contrast returns the WCAG 2 contrast ratio of two hex colours.
+func contrast(a, b string) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// luminance returns the relative luminance of a hex colour.
+func luminance(hex string) float64 {
+	v, _ := strconv.ParseUint(hex[1:], 16, 32)
+	channel := func(c uint64) float64 {
+		s := float64(c) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(v>>16&0xff) + 0.7152*channel(v>>8&0xff) + 0.0722*channel(v&0xff)
+}
@@ -0,0 +1,75 @@
+package theme
+
+import (
+	"math"
+	"testing"
+)
+
+func TestContrast(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"#000000", "#ffffff", 21},
+		{"#ffffff", "#000000", 21},
+		{"#777777", "#ffffff", 4.48},
+		{"#767676", "#ffffff", 4.54},
+		{"#9370db", "#9370db", 1},
+		{"#FFFFFF", "#ffffff", 1},
+	}
+	for _, tt := range tests {
+		if got := contrast(tt.a, tt.b); math.Abs(got-tt.want) > 0.005 {
+			t.Errorf("contrast(%q, %q) = %.3f, want %.2f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPaletteValidate(t *testing.T) {
+	light := Builtin[0].Palette
+	with := func(change func(p *Palette)) Palette {
+		p := light
+		change(&p)
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		palette Palette
+		// fields are the keys Validate should report, or nil for none.
+		fields []string
+	}{
+		{"light", light, nil},
+		{"dark", Builtin[1].Palette, nil},
+		{"short hex", with(func(p *Palette) { p.Text = "#333" }), []string{"text"}},
+		{"named colour", with(func(p *Palette) { p.Accent = "purple" }), []string{"accent"}},
+		{"missing colours", Palette{}, []string{"primary", "secondary", "accent", "background", "text"}},
+		{"text like the background", with(func(p *Palette) { p.Text = p.Background }), []string{"text"}},
+		{"faint heading", with(func(p *Palette) { p.Accent = "#dcdcf0" }), []string{"accent", "primary"}},
+		// #777777 on white is 4.48:1, just under the 4.5:1 body text needs.
+		{"just too faint", with(func(p *Palette) {
+			p.Text, p.Background, p.Primary, p.Secondary = "#777777", "#ffffff", "#ffffff", "#ffffff"
+		}), []string{"text"}},
+		{"just enough", with(func(p *Palette) {
+			p.Text, p.Background, p.Primary, p.Secondary = "#767676", "#ffffff", "#ffffff", "#ffffff"
+		}), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.palette.Validate()
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("Validate() = %v, want errors for %v", errs, tt.fields)
+			}
+			for _, f := range tt.fields {
+				if errs[f] == "" {
+					t.Errorf("Validate() = %v, want an error for %q", errs, f)
+				}
+			}
+		})
+	}
+}
+
+func TestChecksMalformed(t *testing.T) {
+	if checks := (Palette{Text: "#fff"}).Checks(); checks != nil {
+		t.Errorf("Checks() = %v, want nil for malformed colours", checks)
+	}
+}
@@ -0,0 +1,234 @@
+package theme
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"alexdunmow.com/internal/jsonfile"
+)
+
+// StylesheetPath is where the registry's stylesheets are served.
+const StylesheetPath = "/themes.css"
+
+// MaxLabel is the longest theme label accepted, in characters.
+const MaxLabel = 40
+
+// MaxCustom is how many custom themes each user may have.
+const MaxCustom = 10
+
+// ErrNotFound is returned for a custom theme the user doesn't have.
+var ErrNotFound = errors.New("theme not found")
+
+// Registry holds the built-in themes and the custom ones each user creates,
+// persisting the custom ones to a JSON file. Users only see the built-in
+// themes and their own.
+type Registry struct {
+	path string
+
+	mu sync.RWMutex
+	// custom holds each user's themes, keyed like the settings store.
+	custom map[string][]Theme
+	// sheets holds the stylesheet of each user with custom themes, and
+	// under "" the one with just the built-in themes.
+	sheets map[string]stylesheet
+}
+
+// stylesheet is a generated stylesheet and a hash of it.
+type stylesheet struct {
+	css     []byte
+	version string
+}
+
+// NewRegistry opens the registry whose custom themes are saved at path. A
+// missing file means there are none yet.
+func NewRegistry(path string) (*Registry, error) {
+	reg := &Registry{path: path, custom: map[string][]Theme{}}
+
+	if err := jsonfile.Load(path, &reg.custom); err != nil {
+		return nil, fmt.Errorf("loading themes: %w", err)
+	}
+	reg.sheets = map[string]stylesheet{"": build(nil)}
+	for owner := range reg.custom {
+		reg.build(owner)
+	}
+	return reg, nil
+}
+
+// Themes returns the themes owner can choose from, built-in ones first.
+func (reg *Registry) Themes(owner string) []Theme {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return append(slices.Clone(Builtin), reg.custom[owner]...)
+}
+
+// Custom returns the themes owner has created.
+func (reg *Registry) Custom(owner string) []Theme {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return slices.Clone(reg.custom[owner])
+}
+
+// Valid reports whether name is a theme owner can choose.
+func (reg *Registry) Valid(owner, name string) bool {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.valid(owner, name)
+}
+
+func (reg *Registry) valid(owner, name string) bool {
+	named := func(t Theme) bool { return t.Name == name }
+	return slices.ContainsFunc(Builtin, named) || slices.ContainsFunc(reg.custom[owner], named)
+}
+
+func (reg *Registry) validate(owner string, t Theme) map[string]string {
+	errs := t.Palette.Validate()
+	if errs == nil {
+		errs = map[string]string{}
+	}
+
+	label := strings.TrimSpace(t.Label)
+	switch {
+	case len(reg.custom[owner]) >= MaxCustom:
+		errs["label"] = fmt.Sprintf("You can have at most %d custom themes. Delete one to add another.", MaxCustom)
+	case label == "":
+		errs["label"] = "Name is required."
+	case utf8.RuneCountInString(label) > MaxLabel:
+		errs["label"] = fmt.Sprintf("Name must be at most %d characters.", MaxLabel)
+	case t.Name == "" || t.Name != Slug(label):
+		errs["label"] = "Name must contain at least one ASCII letter (a-z) or digit."
+	case reg.valid(owner, t.Name):
+		errs["label"] = "A theme with that name already exists."
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Add validates t and saves it as one of owner's custom themes. It returns
+// the validation errors, if any, or an error if the theme couldn't be saved.
+// t.Name must be Slug(t.Label).
+func (reg *Registry) Add(owner string, t Theme) (map[string]string, error) {
+	t.Label = strings.TrimSpace(t.Label)
+	t.Palette = t.Palette.Normalize()
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if errs := reg.validate(owner, t); errs != nil {
+		return errs, nil
+	}
+	prev := reg.custom[owner]
+	reg.custom[owner] = append(slices.Clone(prev), t)
+	if err := jsonfile.Save(reg.path, reg.custom); err != nil {
+		reg.custom[owner] = prev
+		return nil, err
+	}
+	reg.build(owner)
+	return nil, nil
+}
+
+// Remove deletes owner's custom theme called name. It returns ErrNotFound if
+// owner has no such theme.
+func (reg *Registry) Remove(owner, name string) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	prev := reg.custom[owner]
+	i := slices.IndexFunc(prev, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		return ErrNotFound
+	}
+	if len(prev) == 1 {
+		delete(reg.custom, owner)
+	} else {
+		reg.custom[owner] = slices.Delete(slices.Clone(prev), i, i+1)
+	}
+	if err := jsonfile.Save(reg.path, reg.custom); err != nil {
+		reg.custom[owner] = prev
+		return err
+	}
+	reg.build(owner)
+	return nil
+}
+
+// Stylesheet returns the URL of owner's current stylesheet. It changes
+// whenever owner's themes do, so it can be cached forever.
+func (reg *Registry) Stylesheet(owner string) string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return StylesheetPath + "?v=" + reg.sheet(owner).version
+}
+
+// ServeCSS writes the stylesheet defining the themes owner can choose.
+// Requests for the current version are cached as immutable; others are
+// revalidated. Either way the stylesheet is private to owner.
+func (reg *Registry) ServeCSS(w http.ResponseWriter, r *http.Request, owner string) {
+	reg.mu.RLock()
+	sheet := reg.sheet(owner)
+	reg.mu.RUnlock()
+
+	if r.URL.Query().Get("v") == sheet.version {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("ETag", `"`+sheet.version+`"`)
+	http.ServeContent(w, r, "themes.css", time.Time{}, bytes.NewReader(sheet.css))
+}
+
+func (reg *Registry) sheet(owner string) stylesheet {
+	if s, ok := reg.sheets[owner]; ok {
+		return s
+	}
+	return reg.sheets[""]
+}
+
+// build regenerates owner's stylesheet, or drops it once owner has no custom
+// themes left.
+func (reg *Registry) build(owner string) {
+	if len(reg.custom[owner]) == 0 {
+		delete(reg.sheets, owner)
+		return
+	}
+	reg.sheets[owner] = build(reg.custom[owner])
+}
+
+// build generates the stylesheet for the built-in themes and custom. The
+// browser's colour scheme picks between the light and dark defaults on
+// :root, and each theme's class on <body> overrides them.
+func build(custom []Theme) stylesheet {
+	var b strings.Builder
+	b.WriteString("/* Generated from the theme registry. */\n")
+	writeVars(&b, ":root", "", Builtin[0].Palette)
+	b.WriteString("\n@media (prefers-color-scheme: dark) {\n")
+	writeVars(&b, ":root", "    ", Builtin[1].Palette)
+	b.WriteString("}\n")
+	for _, t := range append(slices.Clone(Builtin), custom...) {
+		b.WriteString("\n/* " + strings.ReplaceAll(t.Label, "*/", "* /") + " */\n")
+		writeVars(&b, "body."+Class(t.Name), "", t.Palette)
+	}
+
+	css := []byte(b.String())
+	sum := sha256.Sum256(css)
+	return stylesheet{css: css, version: hex.EncodeToString(sum[:])[:10]}
+}
+
+func writeVars(b *strings.Builder, selector, indent string, p Palette) {
+	b.WriteString(indent + selector + " {\n")
+	for _, c := range p.Colors() {
+		fmt.Fprintf(b, "%s    --color-%s: %s;\n", indent, c.Field, c.Value)
+	}
+	b.WriteString(indent + "}\n")
+}
@@ -0,0 +1,98 @@
+// Package theme keeps the site's colour themes, generates the stylesheet
+// that defines them and carries the one chosen for a request.
+package theme
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+const (
+	Light = "light"
+	Dark  = "dark"
+)
+
+// Theme is a named palette.
+type Theme struct {
+	// Name identifies the theme in settings and cookies, and gives the
+	// <body> its theme-<Name> class.
+	Name    string  `json:"name"`
+	Label   string  `json:"label"`
+	Palette Palette `json:"palette"`
+}
+
+// Builtin are the themes every registry starts with. Light is also the
+// default, and Dark the default for browsers that prefer a dark scheme.
+var Builtin = []Theme{
+	{Light, "Light", Palette{
+		Primary:    "#e6e6fa", // Light lavender
+		Secondary:  "#ccccff", // Periwinkle
+		Accent:     "#9370db", // Medium purple
+		Background: "#f8f8ff", // Ghost white
+		Text:       "#333333", // Dark gray
+	}},
+	{Dark, "Dark", Palette{
+		Primary:    "#1a1a1a", // Dark charcoal
+		Secondary:  "#333333", // Lighter charcoal
+		Accent:     "#ffee00", // Batman yellow
+		Background: "#0c0c0c", // Near black
+		Text:       "#ffffff", // White
+	}},
+}
+
+// Cookie names the cookie holding an anonymous visitor's theme.
+const Cookie = "theme"
+
+// Class returns the <body> class that applies the named theme, or "" for no
+// theme, which leaves the browser's colour scheme in charge.
+func Class(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "theme-" + name
+}
+
+// Toggle returns the theme the toggle switches to from current. With no
+// current theme, or a custom one, the result depends on the browser, so
+// Toggle returns "".
+func Toggle(current string) string {
+	switch current {
+	case Light:
+		return Dark
+	case Dark:
+		return Light
+	}
+	return ""
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug turns a theme's label into a name: "Sea Breeze!" becomes "sea-breeze".
+// Only ASCII letters and digits are kept, since the name ends up in cookies
+// and class names, so a label without any gives "".
+func Slug(label string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(label), "-"), "-")
+}
+
+// Selection is the theme chosen for a request.
+type Selection struct {
+	// Name is the theme's name, or "" to follow the browser.
+	Name string
+	// Stylesheet is the URL of the stylesheet defining the visitor's themes.
+	Stylesheet string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying sel.
+func NewContext(ctx context.Context, sel Selection) context.Context {
+	return context.WithValue(ctx, contextKey{}, sel)
+}
+
+// FromContext returns the selection in ctx, or the zero Selection if there
+// is none.
+func FromContext(ctx context.Context) Selection {
+	sel, _ := ctx.Value(contextKey{}).(Selection)
+	return sel
+}
@@ -0,0 +1,23 @@
+package theme
+
+import "testing"
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		label, want string
+	}{
+		{"Sea Breeze!", "sea-breeze"},
+		{"  Dark   Mode  ", "dark-mode"},
+		{"ABC 123", "abc-123"},
+		{"--x--", "x"},
+		{"Café au lait", "caf-au-lait"},
+		{"日本", ""},
+		{"!!!", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.label); got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
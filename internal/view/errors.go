@@ -0,0 +1,89 @@
+package view
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/layout"
+	"alexdunmow.com/internal/middleware"
+	g "github.com/maragudk/gomponents"
+	ghttp "github.com/maragudk/gomponents/http"
+)
+
+// statusError is an error that Adapt turns into the given status code.
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e statusError) Error() string   { return e.err.Error() }
+func (e statusError) Unwrap() error   { return e.err }
+func (e statusError) StatusCode() int { return e.code }
+
+// StatusError wraps err so that Adapt responds with code.
+func StatusError(code int, err error) error {
+	return statusError{code, err}
+}
+
+// Adapt is ghttp.Adapt, except that when h returns an error without a node
+// the visitor gets an error page instead of an empty response.
+func Adapt(h ghttp.Handler) http.HandlerFunc {
+	return ghttp.Adapt(func(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+		n, err := h(w, r)
+		if err != nil && n == nil {
+			code := http.StatusInternalServerError
+			var sc interface{ StatusCode() int }
+			if errors.As(err, &sc) {
+				code = sc.StatusCode()
+			}
+			if code >= 500 {
+				slog.ErrorContext(r.Context(), "handler failed", "path", r.URL.Path, "err", err, "request_id", requestID(r))
+			}
+			n = errorPage(w, r, code)
+		}
+		return n, err
+	})
+}
+
+// NotFound responds with the 404 page.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Error(w, r, http.StatusNotFound)
+}
+
+// Error responds with the error page for code.
+func Error(w http.ResponseWriter, r *http.Request, code int) {
+	n := errorPage(w, r, code)
+	w.WriteHeader(code)
+	_ = n.Render(w)
+}
+
+// errorPage returns the error page for code, as a fragment for #main-content
+// on HTMX swaps and a full page otherwise. It sets headers on w but doesn't
+// write the status.
+func errorPage(w http.ResponseWriter, r *http.Request, code int) g.Node {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	Retarget(w, r)
+	return layout.Render(w, r, layout.Page{
+		Title: http.StatusText(code),
+		Body:  components.Error(code, requestID(r)),
+	})
+}
+
+// Retarget points an HTMX fragment request's error response at
+// #main-content. The request may have targeted any element, and htmx only
+// swaps error responses that carry HX-Retarget.
+func Retarget(w http.ResponseWriter, r *http.Request) {
+	if layout.Fragment(r) {
+		w.Header().Set("HX-Retarget", "#main-content")
+		w.Header().Set("HX-Reswap", "innerHTML")
+	}
+}
+
+func requestID(r *http.Request) string {
+	if ctx := middleware.FromContext(r.Context()); ctx != nil {
+		return ctx.RequestID
+	}
+	return ""
+}
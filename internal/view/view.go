@@ -1,18 +1,43 @@
 package view
 
 import (
+	"errors"
+	"io/fs"
 	"net/http"
-	"path/filepath"
+
+	"alexdunmow.com/internal/assets"
+	g "github.com/maragudk/gomponents"
 )
 
-func ServeFavicon(w http.ResponseWriter, r *http.Request) {
-	filePath := "favicon.ico"
-	fullPath := filepath.Join(".", "static", filePath)
-	http.ServeFile(w, r, fullPath)
+// ServeFavicon serves favicon.ico from the static files, or the SVG logo when
+// there is no .ico file.
+func ServeFavicon(m *assets.Manifest) http.HandlerFunc {
+	return Adapt(func(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+		filePath := "favicon.ico"
+		if _, err := fs.Stat(m.FS(), filePath); errors.Is(err, fs.ErrNotExist) {
+			filePath = "svg/logo.svg"
+		}
+		return nil, serve(m, w, r, filePath)
+	})
+}
+
+// ServeStaticFiles serves the files in m under /static/, by plain or
+// fingerprinted name.
+func ServeStaticFiles(m *assets.Manifest) http.HandlerFunc {
+	return Adapt(func(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+		filePath := r.URL.Path[len(assets.Prefix):]
+		if !fs.ValidPath(filePath) || filePath == "." {
+			return nil, StatusError(http.StatusNotFound, fs.ErrNotExist)
+		}
+		return nil, serve(m, w, r, filePath)
+	})
 }
 
-func ServeStaticFiles(w http.ResponseWriter, r *http.Request) {
-	filePath := r.URL.Path[len("/static/"):]
-	fullPath := filepath.Join(".", "static", filePath)
-	http.ServeFile(w, r, fullPath)
+// serve writes the named file from m, making a missing one a 404.
+func serve(m *assets.Manifest, w http.ResponseWriter, r *http.Request, name string) error {
+	err := m.Serve(w, r, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return StatusError(http.StatusNotFound, err)
+	}
+	return err
 }
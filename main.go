@@ -1,54 +1,258 @@
 package main
 
 import (
+	"alexdunmow.com/internal/assets"
+	"alexdunmow.com/internal/auth"
+	"alexdunmow.com/internal/chat"
 	"alexdunmow.com/internal/components"
+	"alexdunmow.com/internal/config"
 	"alexdunmow.com/internal/layout"
+	"alexdunmow.com/internal/middleware"
+	"alexdunmow.com/internal/nav"
+	"alexdunmow.com/internal/progress"
+	"alexdunmow.com/internal/settings"
+	"alexdunmow.com/internal/skills"
+	"alexdunmow.com/internal/theme"
 	"alexdunmow.com/internal/view"
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/joho/godotenv"
 	g "github.com/maragudk/gomponents"
 	ghttp "github.com/maragudk/gomponents/http"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	_ = godotenv.Load()
+	cfg, err := config.Load(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	var cfgErr *config.Error
+	if errors.As(err, &cfgErr) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	if err := run(cfg); err != nil {
+		slog.Error("server failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// compressMinSize is the smallest response body worth compressing, in bytes.
+const compressMinSize = 1024
+
+// run starts the server and blocks until it fails or is told to stop with
+// SIGINT or SIGTERM, in which case it drains open requests first.
+func run(cfg *config.Config) error {
+	logger := newLogger(cfg.LogFormat, cfg.LogLevel)
+	slog.SetDefault(logger)
+
+	settingsStore, err := settings.NewFileStore(cfg.SettingsFile)
+	if err != nil {
+		return err
+	}
+	themes, err := theme.NewRegistry(cfg.ThemesFile)
+	if err != nil {
+		return err
+	}
+	settingsHandler := &settings.Handler{Store: settingsStore, Themes: themes}
+
+	users, err := auth.NewFileStore(cfg.UsersFile)
+	if err != nil {
+		return err
+	}
+	if err := ensureAdmin(users, cfg.AdminEmail, cfg.AdminPassword); err != nil {
+		return err
+	}
+	secret, err := sessionSecret(cfg.SessionSecret)
+	if err != nil {
+		return err
+	}
+	sessions := &auth.Sessions{Secret: secret, TTL: 7 * 24 * time.Hour}
+	authHandler := &auth.Handler{Users: users, Sessions: sessions}
+
+	chatHub, err := chat.NewHub(100, cfg.ChatLog)
+	if err != nil {
+		return err
+	}
+	defer chatHub.Close()
+	chatHandler := &chat.Handler{Hub: chatHub}
+
+	skillGraph, err := loadSkills(cfg)
+	if err != nil {
+		return err
+	}
+	skillsAPI := &skills.Handler{Graph: skillGraph}
+
+	progressStore, err := progress.NewFileStore(cfg.ProgressFile)
+	if err != nil {
+		return err
+	}
+	progressAPI := &progress.Handler{Store: progressStore, Graph: skillGraph}
+
+	static, err := staticFiles(cfg)
+	if err != nil {
+		return err
+	}
+	manifest, err := assets.New(static, !cfg.Dev)
+	if err != nil {
+		return err
+	}
+	assets.SetDefault(manifest)
+	if !manifest.Exists(layout.HTMXFile) {
+		return fmt.Errorf("static file %s is missing; run npm install && npm run vendor in static/ts", layout.HTMXFile)
+	}
+
+	protected := middleware.Adapt(middleware.RequireUser)
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /favicon.ico", view.ServeFavicon)
-	mux.HandleFunc("GET /static/", view.ServeStaticFiles)
+	mux.HandleFunc("GET /favicon.ico", view.ServeFavicon(manifest))
+	mux.HandleFunc("GET /static/", view.ServeStaticFiles(manifest))
 
-	mux.HandleFunc("GET /dashboard", ghttp.Adapt(dashboardHandler))
-	mux.HandleFunc("GET /settings", ghttp.Adapt(settingsHandler))
-	mux.HandleFunc("GET /skills", ghttp.Adapt(skillsHandler))
-	mux.HandleFunc("GET /", ghttp.Adapt(func(w http.ResponseWriter, r *http.Request) (g.Node, error) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return nil, nil
-		}
+	mux.HandleFunc("POST /login", view.Adapt(authHandler.Login))
+	mux.HandleFunc("POST /logout", view.Adapt(authHandler.Logout))
+
+	mux.HandleFunc("GET /chat/events", chatHandler.Events)
+	mux.HandleFunc("POST /send-message", view.Adapt(chatHandler.Send))
+
+	mux.HandleFunc("GET /api/skills", skillsAPI.List)
+	mux.HandleFunc("GET /api/skills/export", skillsAPI.Export)
+	mux.HandleFunc("GET /api/skills/{name}", skillsAPI.Show)
+	mux.HandleFunc("GET /api/skills/{name}/children", skillsAPI.Children)
+	mux.HandleFunc("POST /api/skills/{name}/unlock", progressAPI.Unlock)
+	mux.HandleFunc("GET /api/progress", progressAPI.Show)
 
-		return homeHandler(w, r)
-	}))
+	mux.Handle("POST /api/settings", protected(view.Adapt(settingsHandler.Update)))
+	mux.HandleFunc("POST /theme", view.Adapt(settingsHandler.SetTheme))
+	mux.HandleFunc("GET "+theme.StylesheetPath, settingsHandler.ServeCSS)
+	mux.Handle("POST /api/themes", protected(view.Adapt(settingsHandler.CreateTheme)))
+	mux.Handle("POST /api/themes/{name}/delete", protected(view.Adapt(settingsHandler.DeleteTheme)))
+	mux.Handle("POST /api/themes/preview", protected(view.Adapt(settingsHandler.PreviewTheme)))
+	mux.HandleFunc("GET /skills/{name}", view.Adapt(skillHandler(skillGraph)))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	pages := &nav.Registry{
+		SignedIn: func(r *http.Request) bool { return middleware.CurrentUser(r) != nil },
+		Protect:  protected,
 	}
+	pages.Add(nav.Item{Key: "home", Label: "Home", Icon: "🏠", Path: "/", Order: 10,
+		Handler: view.Adapt(homeHandler)})
+	pages.Add(nav.Item{Key: "dashboard", Label: "Dashboard", Icon: "📊", Path: "/dashboard", Order: 20, AuthOnly: true,
+		Handler: view.Adapt(dashboardHandler)})
+	pages.Add(nav.Item{Key: "skills", Label: "Skill Tree", Icon: "🌳", Path: "/skills", Order: 30,
+		Handler: view.Adapt(skillsHandler(skillGraph.Layout()))})
+	pages.Add(nav.Item{Key: "settings", Label: "Settings", Icon: "⚙️", Path: "/settings", Order: 40, AuthOnly: true,
+		Handler: view.Adapt(settingsHandler.Show)})
+	pages.Add(nav.Item{Key: "login", Label: "Login", Icon: "🔑", Path: "/login", Order: 50, GuestOnly: true,
+		Handler: view.Adapt(authHandler.ShowLogin)})
+	pages.Routes(mux)
+	nav.SetDefault(pages)
 
-	fmt.Printf("server is running on port %s\n", port)
-	err := http.ListenAndServe(":"+port, mux)
+	mux.HandleFunc("GET /", view.NotFound)
+
+	handler := middleware.Chain(mux,
+		middleware.Context,
+		middleware.RequestID,
+		middleware.Log(logger, cfg.TrustedProxies),
+		middleware.Compress(compressMinSize),
+		middleware.Adapt(middleware.LoadUser(users, sessions)),
+		settingsHandler.LoadTheme,
+	)
+	server := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	// Shutdown waits for handlers to return, so end the chat event streams.
+	server.RegisterOnShutdown(func() { chatHub.Close() })
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		fmt.Println(err)
+		return err
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.TLS() {
+			serveErr <- server.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			serveErr <- server.Serve(listener)
+		}
+	}()
+	slog.Info("server is running", "addr", listener.Addr().String(), "tls", cfg.TLS(), "dev", cfg.Dev)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("shutting down", "timeout", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("shutting down: %w", err)
 	}
+	return nil
 }
 
-func homeHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
-	if r.Header.Get("HX-Request") == "true" {
-		return components.Home(), nil
-	} else {
-		return layout.HomePage(), nil
+// newLogger returns a logger writing to stderr in format, "text" or "json".
+func newLogger(format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// ensureAdmin creates the bootstrap account from ADMIN_EMAIL and
+// ADMIN_PASSWORD if it doesn't exist yet, so a fresh install can log in.
+func ensureAdmin(users auth.UserStore, email, password string) error {
+	if email == "" || password == "" {
+		return nil
+	}
+	_, err := users.ByEmail(auth.NormalizeEmail(email))
+	if errors.Is(err, auth.ErrUserNotFound) {
+		_, err = users.Create(email, password)
+	}
+	return err
+}
+
+// sessionSecret returns secret, or a random key if it is empty. A random key
+// means sessions don't survive a restart.
+func sessionSecret(secret string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+	slog.Warn("SESSION_SECRET is not set; using a random key, sessions will end on restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
 	}
+	return key, nil
+}
+
+func homeHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+	return layout.Render(w, r, layout.Page{Title: "Home", Active: "home", Body: components.Home()}), nil
 }
 
 func dashboardHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
@@ -58,44 +262,52 @@ func dashboardHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
 		TotalRevenue: 50000.00,
 	}
 
-	if r.Header.Get("HX-Request") == "true" {
-		return components.Dashboard(data), nil
-	} else {
-		return layout.DashboardPage(data), nil
-	}
+	return layout.Render(w, r, layout.Page{Title: "Dashboard", Active: "dashboard", Body: components.Dashboard(data)}), nil
 }
 
-func skillsHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+// skillsHandler renders the skill tree. The diagram is laid out once at
+// startup since the graph never changes while the server runs.
+func skillsHandler(diagram *skills.Diagram) ghttp.Handler {
+	return func(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+		view := r.URL.Query().Get("view")
 
-	if r.Header.Get("HX-Request") == "true" {
-		return components.SkillTree(), nil
-	} else {
-		return layout.SkillsPage(), nil
+		return layout.Render(w, r, layout.Page{Title: "Skill Tree", Active: "skills", Body: components.Skills(diagram, view)}), nil
 	}
 }
 
-func settingsHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
-	settings := components.UserSettings{
-		Email:           "user@example.com",
-		NotificationsOn: true,
-		Theme:           "light",
-	}
+// skillHandler renders the page of the skill named in the path.
+func skillHandler(graph *skills.Graph) ghttp.Handler {
+	return func(w http.ResponseWriter, r *http.Request) (g.Node, error) {
+		name := r.PathValue("name")
 
-	if r.Header.Get("HX-Request") == "true" {
-		return components.Settings(settings), nil
-	} else {
-		return layout.SettingsPage(settings), nil
-	}
-}
+		skill, err := graph.Get(name)
+		if errors.Is(err, skills.ErrNotFound) {
+			page := layout.Page{Title: "Skill not found", Active: "skills", Body: components.SkillNotFound(name)}
+			view.Retarget(w, r)
+			return layout.Render(w, r, page), view.StatusError(http.StatusNotFound, err)
+		}
+		if err != nil {
+			return nil, err
+		}
 
-func updateSettingsHandler(w http.ResponseWriter, r *http.Request) (g.Node, error) {
-	// Handle form submission and update settings
-	// For now, we'll just render the settings form again
-	settings := components.UserSettings{
-		Email:           r.FormValue("email"),
-		NotificationsOn: r.FormValue("notifications") == "on",
-		Theme:           r.FormValue("theme"),
-	}
+		data := components.SkillData{Skill: skill}
+		if data.Parents, err = graph.Parents(name); err != nil {
+			return nil, err
+		}
+		if data.Children, err = graph.Children(name); err != nil {
+			return nil, err
+		}
+		if data.Siblings, err = graph.Siblings(name); err != nil {
+			return nil, err
+		}
+		for _, root := range graph.Roots() {
+			paths, err := graph.Paths(root.Name, name, 10)
+			if err != nil {
+				return nil, err
+			}
+			data.Paths = append(data.Paths, paths...)
+		}
 
-	return components.Settings(settings), nil
+		return layout.Render(w, r, layout.Page{Title: skill.Name, Active: "skills", Body: components.SkillDetail(data)}), nil
+	}
 }
@@ -11,6 +11,10 @@
       this.startX = 0;
       this.startY = 0;
       this.TO_RADIANS = Math.PI / 180;
+      this.skills = {};
+      this.cells = /* @__PURE__ */ new Map();
+      this.unlocked = /* @__PURE__ */ new Set();
+      this.dragged = false;
       this.hoveredHex = null;
       this.canvas = canvas;
       const context = this.canvas.getContext("2d");
@@ -30,18 +34,150 @@
       this.setLineWidth(12);
       this.drawHexagonGrid(this.canvas.width / 2, this.canvas.height / 2, 50, 1);
       this.attachEventListeners();
+      this.loadSkills();
+    }
+    // Fetches the skills and the visitor's saved progress, then draws the
+    // tree in place of the placeholder grid.
+    loadSkills() {
+      Promise.all([
+        fetch("/api/skills").then((response) => response.json()),
+        fetch("/api/progress").then((response) => response.json())
+      ]).then(
+        ([skills, progress]) => {
+          this.skills = skills;
+          this.unlocked = new Set(progress.unlocked);
+          this.layoutSkills();
+          this.redraw();
+        }
+      ).catch((error) => console.error("could not load skills", error));
+    }
+    // Places every skill in the column of its depth below the roots, centring
+    // each column on the middle row.
+    layoutSkills() {
+      const children = /* @__PURE__ */ new Set();
+      for (const name in this.skills) {
+        this.skills[name].children.forEach((child) => children.add(child));
+      }
+      const depth = /* @__PURE__ */ new Map();
+      let level = Object.keys(this.skills).filter((name) => !children.has(name)).sort();
+      const columns = [];
+      while (level.length > 0) {
+        level.forEach((name) => depth.set(name, columns.length));
+        columns.push(level);
+        const next = [];
+        for (const name of level) {
+          for (const child of this.skills[name].children) {
+            if (this.skills[child] && !depth.has(child) && !next.includes(child)) {
+              next.push(child);
+            }
+          }
+        }
+        level = next;
+      }
+      this.cells.clear();
+      const firstCol = -Math.floor((columns.length - 1) / 2);
+      columns.forEach((column, i) => {
+        const firstRow = -Math.floor((column.length - 1) / 2);
+        column.forEach((name, j) => {
+          this.cells.set(`${firstCol + i},${firstRow + j}`, name);
+        });
+      });
+    }
+    // Whether the server would let the visitor unlock name: it is locked and
+    // is either a root or the child of an unlocked skill.
+    canUnlock(name) {
+      if (this.unlocked.has(name)) return false;
+      let isRoot = true;
+      for (const key in this.skills) {
+        if (this.skills[key].children.includes(name)) {
+          if (this.unlocked.has(key)) return true;
+          isRoot = false;
+        }
+      }
+      return isRoot;
+    }
+    // Unlocks the skill locally straight away, then asks the server to persist
+    // it. The server enforces the same rule as canUnlock, so a rejection means
+    // local state is stale and the unlock is rolled back.
+    unlock(name) {
+      this.unlocked.add(name);
+      this.redraw();
+      const rollback = () => {
+        this.unlocked.delete(name);
+        this.redraw();
+      };
+      fetch(`/api/skills/${encodeURIComponent(name)}/unlock`, { method: "POST" }).then((response) => {
+        if (!response.ok) {
+          rollback();
+        }
+      }).catch((error) => {
+        console.error("could not save progress", error);
+        rollback();
+      });
     }
     resizeCanvas() {
       this.canvas.width = this.canvas.offsetWidth;
       this.canvas.height = this.canvas.offsetHeight;
+      this.redraw();
+    }
+    // Draws the skill tree, or the placeholder grid until it has loaded.
+    redraw() {
       this.clear();
-      this.drawHexagonGrid(this.canvas.width / 2, this.canvas.height / 2, 50, 1);
+      if (this.cells.size === 0) {
+        this.drawHexagonGrid(this.canvas.width / 2 + this.offsetX, this.canvas.height / 2 + this.offsetY, 50, 1);
+        return;
+      }
+      this.cells.forEach((name, key) => {
+        const [col, row] = key.split(",").map(Number);
+        this.drawSkill(col, row, this.skills[name]);
+      });
+    }
+    // Draws skill in its cell, coloured by whether it is unlocked, can be
+    // unlocked next, or is still locked.
+    drawSkill(col, row, skill) {
+      const { x, y } = this.cellCenter(col, row);
+      const hovered = this.hoveredHex !== null &&
+        this.hoveredHex.col === col &&
+        this.hoveredHex.row === row;
+      let fill = "#333";
+      if (this.unlocked.has(skill.name)) {
+        fill = "#4CAF50";
+      } else if (this.canUnlock(skill.name)) {
+        fill = "#2f0775";
+      }
+      this.drawHexagon(x, y, this.hexSize * 0.85, 12, fill, hovered ? "#61dafb" : "black", hovered ? 4 : 2);
+      this.ctx.fillStyle = "white";
+      this.ctx.textAlign = "center";
+      this.ctx.textBaseline = "middle";
+      this.ctx.font = "20px Arial";
+      this.ctx.fillText(skill.icon, x, y - 10);
+      this.ctx.font = "11px Arial";
+      this.ctx.fillText(skill.name, x, y + 14, this.hexSize * 1.3);
+    }
+    // Returns the canvas position of the centre of the cell at col, row.
+    // Odd columns sit half a row lower.
+    cellCenter(col, row) {
+      return this.createPoint(this.canvas.width / 2 + this.offsetX + col * this.horizontalSpacing, this.canvas.height / 2 +
+        this.offsetY +
+        row * this.verticalSpacing +
+        (col % 2 === 0 ? 0 : this.verticalSpacing / 2));
+    }
+    // Returns the cell under the canvas position x, y.
+    cellAt(x, y) {
+      const col = Math.round((x - this.canvas.width / 2 - this.offsetX) / this.horizontalSpacing);
+      const row = Math.round((y -
+        this.canvas.height / 2 -
+        this.offsetY -
+        (col % 2 === 0 ? 0 : this.verticalSpacing / 2)) /
+        this.verticalSpacing);
+      return { row, col };
     }
     attachEventListeners() {
       this.canvas.addEventListener("mousedown", (e) => this.onMouseDown(e));
       this.canvas.addEventListener("mousemove", (e) => this.onMouseMove(e));
       this.canvas.addEventListener("mouseup", () => this.onMouseUp());
       this.canvas.addEventListener("mouseleave", () => this.onMouseUp());
+      this.canvas.addEventListener("click", (e) => this.onClick(e));
       this.canvas.addEventListener(
         "mousemove",
         (event) => this.onMouseHover(event)
@@ -49,6 +185,7 @@
     }
     onMouseDown(event) {
       this.isDragging = true;
+      this.dragged = false;
       this.lastMouseX = event.clientX;
       this.lastMouseY = event.clientY;
       this.startX = this.offsetX;
@@ -60,18 +197,25 @@
         const deltaY = event.clientY - this.lastMouseY;
         this.offsetX = this.startX + deltaX;
         this.offsetY = this.startY + deltaY;
-        this.clear();
-        this.drawHexagonGrid(
-          this.canvas.width / 2 + this.offsetX,
-          this.canvas.height / 2 + this.offsetY,
-          50,
-          3
-        );
+        if (Math.abs(deltaX) + Math.abs(deltaY) > 3) {
+          this.dragged = true;
+        }
+        this.redraw();
       }
     }
     onMouseUp() {
       this.isDragging = false;
     }
+    onClick(event) {
+      if (this.dragged) {
+        return;
+      }
+      const { row, col } = this.cellAt(event.offsetX, event.offsetY);
+      const name = this.cells.get(`${col},${row}`);
+      if (name && this.canUnlock(name)) {
+        this.unlock(name);
+      }
+    }
     createPoint(x, y) {
       return { x, y };
     }
@@ -148,64 +292,18 @@
         }
       }
     }
-    attachHoverEvent() {
-      this.canvas.addEventListener(
-        "mousemove",
-        (event) => this.onMouseHover(event)
-      );
-    }
     onMouseHover(event) {
-      const mouseX = event.offsetX;
-      const mouseY = event.offsetY;
-      const hexSize = 50;
-      const hexHeight = Math.sqrt(3) * hexSize;
-      const hexWidth = 2 * hexSize;
-      const horizontalSpacing = hexWidth * 0.75;
-      const verticalSpacing = hexHeight;
-      const offsetX = mouseX - this.canvas.width / 2 - this.offsetX;
-      const offsetY = mouseY - this.canvas.height / 2 - this.offsetY;
-      const q = Math.round(offsetX / horizontalSpacing);
-      const r = Math.round(
-        (offsetY - (q % 2 === 0 ? 0 : verticalSpacing / 2)) / verticalSpacing
-      );
-      const hexNumber = this.calculateHexNumber(q, r);
-      if (this.hoveredHex && this.hoveredHex.row === r && this.hoveredHex.col === q) {
+      const { row, col } = this.cellAt(event.offsetX, event.offsetY);
+      if (this.hoveredHex &&
+        this.hoveredHex.row === row &&
+        this.hoveredHex.col === col) {
         return;
       }
-      if (this.hoveredHex) {
-        this.redrawHexagon(this.hoveredHex.row, this.hoveredHex.col, hexSize);
-      }
-      const centerX = this.canvas.width / 2 + this.offsetX + q * horizontalSpacing;
-      const centerY = this.canvas.height / 2 + this.offsetY + r * verticalSpacing + (q % 2 === 0 ? 0 : verticalSpacing / 2);
-      this.drawHexagon(centerX, centerY, hexSize, 12, "#FF0000");
-      this.displayHexNumber(centerX, centerY, hexNumber);
-      this.hoveredHex = { row: r, col: q };
-    }
-    // Helper function to calculate the unique hexagon number based on axial coordinates (q, r)
-    calculateHexNumber(q, r) {
-      if (q === 0 && r === 0) return 0;
-      const s = -q - r;
-      const x = q - r;
-      const y = r * 2 + q;
-      const ring = Math.max(Math.abs(q), Math.abs(r), Math.abs(s));
-      if (ring === 1) {
-        if (q === 0 && r === -1) return 2;
-        if (q === 1 && r === -1) return 3;
-        if (q === 1 && r === 0) return 4;
-        if (q === 0 && r === 1) return 5;
-        if (q === -1 && r === 1) return 6;
-        if (q === -1 && r === 0) return 1;
-      }
-      let number = 3 * ring * (ring - 1) + 2;
-      if (y > x) {
-        if (y > -x) number += 5 * ring - r - q;
-        else number += 1 * ring - r;
-      } else {
-        if (y > -x) number += 3 * ring + r;
-        else if (y < -x - ring) number += 7 * ring + r + q;
-        else number += 9 * ring + r + q;
-      }
-      return number;
+      this.hoveredHex = { row, col };
+      const name = this.cells.get(`${col},${row}`);
+      this.canvas.style.cursor =
+        name && this.canUnlock(name) ? "pointer" : "default";
+      this.redraw();
     }
     // Helper function to display hexagon number inside the hexagon
     displayHexNumber(centerX, centerY, number) {
@@ -215,19 +313,6 @@
       this.ctx.textBaseline = "middle";
       this.ctx.fillText(number.toString(), centerX, centerY);
     }
-    // Helper function to redraw the hexagon with its original color
-    redrawHexagon(row, col, hexSize) {
-      const hexHeight = Math.sqrt(3) * hexSize;
-      const hexWidth = 2 * hexSize;
-      const horizontalSpacing = hexWidth * 0.75;
-      const verticalSpacing = hexHeight;
-      const centerX = this.canvas.width / 2 + this.offsetX + col * horizontalSpacing;
-      const centerY = this.canvas.height / 2 + this.offsetY + row * verticalSpacing + (col % 2 === 1 ? verticalSpacing / 2 : 0);
-      const originalFillStyle = (row + col) % 2 === 0 ? "#333" : "#61dafb";
-      this.drawHexagon(centerX, centerY, hexSize, 12, originalFillStyle);
-      const hexNumber = this.calculateHexNumber(col, row);
-      this.displayHexNumber(centerX, centerY, hexNumber);
-    }
     clear() {
       this.ctx.clearRect(0, 0, this.canvas.width, this.canvas.height);
     }
@@ -246,6 +331,7 @@
   };
 
   // index.ts
+  var chatEvents;
   window.alexdunmow = {
     get: function(id) {
       const el = document.getElementById(id);
@@ -258,18 +344,28 @@
       document.body.addEventListener(
         "htmx:beforeSwap",
         (event) => {
-          const path = event.detail.pathInfo.finalRequestPath;
-          const links = document.getElementsByClassName("active-link");
-          if (links) {
-            Array.from(links).forEach((link) => {
-              link.classList.remove("active-link");
-            });
+          const xhr = event.detail.xhr;
+          if (xhr.status >= 400 && xhr.getResponseHeader("HX-Retarget")) {
+            event.detail.shouldSwap = true;
+            event.detail.isError = false;
           }
-          const activeLink = document.querySelector(
-            `a.sidebar-link[href="${path}"]`
-          );
-          if (activeLink) {
-            activeLink.classList.add("active-link");
+        }
+      );
+      document.body.addEventListener(
+        "theme-changed",
+        (event) => {
+          const themes = alexdunmow.get("themes-css");
+          if (themes.getAttribute("href") !== event.detail.stylesheet) {
+            themes.setAttribute("href", event.detail.stylesheet);
+          }
+          const classes = Array.from(document.body.classList);
+          for (const c of classes) {
+            if (c.startsWith("theme-")) {
+              document.body.classList.remove(c);
+            }
+          }
+          if (event.detail.theme) {
+            document.body.classList.add("theme-" + event.detail.theme);
           }
         }
       );
@@ -277,28 +373,15 @@
     init: function() {
       console.log("hey, welcome to my website!");
     },
-    initSidebar: function() {
-      const themeSwitcher = document.getElementById("theme-switcher");
-      if (!themeSwitcher) return;
-      themeSwitcher.addEventListener("click", function() {
-        document.body.classList.toggle("dark");
-      });
-    },
     initChatSidebar: function() {
-      const chatInput = alexdunmow.get("chat-input");
-      const sendMessageButton = alexdunmow.get("send-message");
-      sendMessageButton.addEventListener("click", function() {
-        const message = chatInput.value;
-        if (message) {
-          const chatMessagesContainer = alexdunmow.get("chat-messages");
-          chatMessagesContainer.innerHTML += `
-            <div class="bg-accent p-2 rounded-lg shadow self-end text-primary">
-                <p class="text-sm"><strong>You:</strong> ${message}</p>
-            </div>
-        `;
-          chatInput.value = "";
-          chatMessagesContainer.scrollTop = chatMessagesContainer.scrollHeight;
-        }
+      const chatMessagesContainer = alexdunmow.get("chat-messages");
+      if (chatEvents) {
+        chatEvents.close();
+      }
+      chatEvents = new EventSource("/chat/events");
+      chatEvents.addEventListener("message", (event) => {
+        chatMessagesContainer.insertAdjacentHTML("beforeend", event.data);
+        chatMessagesContainer.scrollTop = chatMessagesContainer.scrollHeight;
       });
     },
     initSkillTree: function(canvasElement) {
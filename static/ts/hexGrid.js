@@ -11,6 +11,14 @@ class HexagonCanvas {
         this.startX = 0;
         this.startY = 0;
         this.TO_RADIANS = Math.PI / 180;
+        // The skill tree, laid out one depth per column: cells maps a "col,row"
+        // key to the skill drawn there.
+        this.skills = {};
+        this.cells = new Map();
+        this.unlocked = new Set();
+        // Whether the mouse moved while pressed, so the click ending a pan
+        // doesn't unlock the skill under it.
+        this.dragged = false;
         this.hoveredHex = null;
         this.canvas = canvas;
         const context = this.canvas.getContext("2d");
@@ -32,24 +40,165 @@ class HexagonCanvas {
         this.drawHexagonGrid(this.canvas.width / 2, this.canvas.height / 2, 50, 1);
         // Attach event listeners for panning
         this.attachEventListeners();
+        this.loadSkills();
+    }
+    // Fetches the skills and the visitor's saved progress, then draws the
+    // tree in place of the placeholder grid.
+    loadSkills() {
+        Promise.all([
+            fetch("/api/skills").then((response) => response.json()),
+            fetch("/api/progress").then((response) => response.json()),
+        ])
+            .then(([skills, progress]) => {
+            this.skills = skills;
+            this.unlocked = new Set(progress.unlocked);
+            this.layoutSkills();
+            this.redraw();
+        })
+            .catch((error) => console.error("could not load skills", error));
+    }
+    // Places every skill in the column of its depth below the roots, centring
+    // each column on the middle row.
+    layoutSkills() {
+        const children = new Set();
+        for (const name in this.skills) {
+            this.skills[name].children.forEach((child) => children.add(child));
+        }
+        const depth = new Map();
+        let level = Object.keys(this.skills)
+            .filter((name) => !children.has(name))
+            .sort();
+        const columns = [];
+        while (level.length > 0) {
+            level.forEach((name) => depth.set(name, columns.length));
+            columns.push(level);
+            const next = [];
+            for (const name of level) {
+                for (const child of this.skills[name].children) {
+                    if (this.skills[child] && !depth.has(child) && !next.includes(child)) {
+                        next.push(child);
+                    }
+                }
+            }
+            level = next;
+        }
+        this.cells.clear();
+        const firstCol = -Math.floor((columns.length - 1) / 2);
+        columns.forEach((column, i) => {
+            const firstRow = -Math.floor((column.length - 1) / 2);
+            column.forEach((name, j) => {
+                this.cells.set(`${firstCol + i},${firstRow + j}`, name);
+            });
+        });
+    }
+    // Whether the server would let the visitor unlock name: it is locked and
+    // is either a root or the child of an unlocked skill.
+    canUnlock(name) {
+        if (this.unlocked.has(name))
+            return false;
+        let isRoot = true;
+        for (const key in this.skills) {
+            if (this.skills[key].children.includes(name)) {
+                if (this.unlocked.has(key))
+                    return true;
+                isRoot = false;
+            }
+        }
+        return isRoot;
+    }
+    // Unlocks the skill locally straight away, then asks the server to persist
+    // it. The server enforces the same rule as canUnlock, so a rejection means
+    // local state is stale and the unlock is rolled back.
+    unlock(name) {
+        this.unlocked.add(name);
+        this.redraw();
+        const rollback = () => {
+            this.unlocked.delete(name);
+            this.redraw();
+        };
+        fetch(`/api/skills/${encodeURIComponent(name)}/unlock`, { method: "POST" })
+            .then((response) => {
+            if (!response.ok) {
+                rollback();
+            }
+        })
+            .catch((error) => {
+            console.error("could not save progress", error);
+            rollback();
+        });
     }
     resizeCanvas() {
         // Set the canvas width and height to the CSS size of the element
         this.canvas.width = this.canvas.offsetWidth;
         this.canvas.height = this.canvas.offsetHeight;
         // Clear the canvas and redraw everything
+        this.redraw();
+    }
+    // Draws the skill tree, or the placeholder grid until it has loaded.
+    redraw() {
         this.clear();
-        this.drawHexagonGrid(this.canvas.width / 2, this.canvas.height / 2, 50, 1);
+        if (this.cells.size === 0) {
+            this.drawHexagonGrid(this.canvas.width / 2 + this.offsetX, this.canvas.height / 2 + this.offsetY, 50, 1);
+            return;
+        }
+        this.cells.forEach((name, key) => {
+            const [col, row] = key.split(",").map(Number);
+            this.drawSkill(col, row, this.skills[name]);
+        });
+    }
+    // Draws skill in its cell, coloured by whether it is unlocked, can be
+    // unlocked next, or is still locked.
+    drawSkill(col, row, skill) {
+        const { x, y } = this.cellCenter(col, row);
+        const hovered = this.hoveredHex !== null &&
+            this.hoveredHex.col === col &&
+            this.hoveredHex.row === row;
+        let fill = "#333";
+        if (this.unlocked.has(skill.name)) {
+            fill = "#4CAF50";
+        }
+        else if (this.canUnlock(skill.name)) {
+            fill = "#2f0775";
+        }
+        // Slightly smaller than the cell so neighbours don't overlap.
+        this.drawHexagon(x, y, this.hexSize * 0.85, 12, fill, hovered ? "#61dafb" : "black", hovered ? 4 : 2);
+        this.ctx.fillStyle = "white";
+        this.ctx.textAlign = "center";
+        this.ctx.textBaseline = "middle";
+        this.ctx.font = "20px Arial";
+        this.ctx.fillText(skill.icon, x, y - 10);
+        this.ctx.font = "11px Arial";
+        this.ctx.fillText(skill.name, x, y + 14, this.hexSize * 1.3);
+    }
+    // Returns the canvas position of the centre of the cell at col, row.
+    // Odd columns sit half a row lower.
+    cellCenter(col, row) {
+        return this.createPoint(this.canvas.width / 2 + this.offsetX + col * this.horizontalSpacing, this.canvas.height / 2 +
+            this.offsetY +
+            row * this.verticalSpacing +
+            (col % 2 === 0 ? 0 : this.verticalSpacing / 2));
+    }
+    // Returns the cell under the canvas position x, y.
+    cellAt(x, y) {
+        const col = Math.round((x - this.canvas.width / 2 - this.offsetX) / this.horizontalSpacing);
+        const row = Math.round((y -
+            this.canvas.height / 2 -
+            this.offsetY -
+            (col % 2 === 0 ? 0 : this.verticalSpacing / 2)) /
+            this.verticalSpacing);
+        return { row, col };
     }
     attachEventListeners() {
         this.canvas.addEventListener("mousedown", (e) => this.onMouseDown(e));
         this.canvas.addEventListener("mousemove", (e) => this.onMouseMove(e));
         this.canvas.addEventListener("mouseup", () => this.onMouseUp());
         this.canvas.addEventListener("mouseleave", () => this.onMouseUp());
+        this.canvas.addEventListener("click", (e) => this.onClick(e));
         this.canvas.addEventListener("mousemove", (event) => this.onMouseHover(event));
     }
     onMouseDown(event) {
         this.isDragging = true;
+        this.dragged = false;
         // Store the starting mouse position
         this.lastMouseX = event.clientX;
         this.lastMouseY = event.clientY;
@@ -65,14 +214,26 @@ class HexagonCanvas {
             // Update the offset
             this.offsetX = this.startX + deltaX;
             this.offsetY = this.startY + deltaY;
+            if (Math.abs(deltaX) + Math.abs(deltaY) > 3) {
+                this.dragged = true;
+            }
             // Redraw the hexagons with the new offset
-            this.clear();
-            this.drawHexagonGrid(this.canvas.width / 2 + this.offsetX, this.canvas.height / 2 + this.offsetY, 50, 3);
+            this.redraw();
         }
     }
     onMouseUp() {
         this.isDragging = false;
     }
+    onClick(event) {
+        if (this.dragged) {
+            return;
+        }
+        const { row, col } = this.cellAt(event.offsetX, event.offsetY);
+        const name = this.cells.get(`${col},${row}`);
+        if (name && this.canUnlock(name)) {
+            this.unlock(name);
+        }
+    }
     createPoint(x, y) {
         return { x, y };
     }
@@ -139,87 +300,19 @@ class HexagonCanvas {
             }
         }
     }
-    attachHoverEvent() {
-        this.canvas.addEventListener("mousemove", (event) => this.onMouseHover(event));
-    }
     onMouseHover(event) {
-        const mouseX = event.offsetX;
-        const mouseY = event.offsetY;
-        // Define hexagon size and positioning
-        const hexSize = 50;
-        const hexHeight = Math.sqrt(3) * hexSize; // Height of a hexagon
-        const hexWidth = 2 * hexSize; // Width of a hexagon
-        const horizontalSpacing = hexWidth * 0.75; // Horizontal distance between adjacent hexagons
-        const verticalSpacing = hexHeight; // Vertical distance between adjacent rows
-        // Calculate the offset from the center of the canvas
-        const offsetX = mouseX - this.canvas.width / 2 - this.offsetX;
-        const offsetY = mouseY - this.canvas.height / 2 - this.offsetY;
-        // Calculate approximate column and row index in hexagonal grid (axial coordinates)
-        const q = Math.round(offsetX / horizontalSpacing);
-        const r = Math.round((offsetY - (q % 2 === 0 ? 0 : verticalSpacing / 2)) / verticalSpacing);
-        // Calculate the unique hexagon number based on axial coordinates
-        const hexNumber = this.calculateHexNumber(q, r);
+        const { row, col } = this.cellAt(event.offsetX, event.offsetY);
         // If the hovered hexagon is the same as the previously hovered one, do nothing
         if (this.hoveredHex &&
-            this.hoveredHex.row === r &&
-            this.hoveredHex.col === q) {
+            this.hoveredHex.row === row &&
+            this.hoveredHex.col === col) {
             return;
         }
-        // Redraw the previously hovered hexagon (restore its original state)
-        if (this.hoveredHex) {
-            this.redrawHexagon(this.hoveredHex.row, this.hoveredHex.col, hexSize);
-        }
-        // Draw the new hovered hexagon with a different color
-        const centerX = this.canvas.width / 2 + this.offsetX + q * horizontalSpacing;
-        const centerY = this.canvas.height / 2 +
-            this.offsetY +
-            r * verticalSpacing +
-            (q % 2 === 0 ? 0 : verticalSpacing / 2);
-        this.drawHexagon(centerX, centerY, hexSize, 12, "#FF0000"); // Change the color to red when hovered
-        // Display the hexagon number inside the hovered hexagon
-        this.displayHexNumber(centerX, centerY, hexNumber);
-        // Update the currently hovered hexagon
-        this.hoveredHex = { row: r, col: q };
-    }
-    // Helper function to calculate the unique hexagon number based on axial coordinates (q, r)
-    calculateHexNumber(q, r) {
-        if (q === 0 && r === 0)
-            return 0;
-        const s = -q - r;
-        const x = q - r;
-        const y = r * 2 + q;
-        const ring = Math.max(Math.abs(q), Math.abs(r), Math.abs(s));
-        if (ring === 1) {
-            // Special case for the first ring
-            if (q === 0 && r === -1)
-                return 2;
-            if (q === 1 && r === -1)
-                return 3;
-            if (q === 1 && r === 0)
-                return 4;
-            if (q === 0 && r === 1)
-                return 5;
-            if (q === -1 && r === 1)
-                return 6;
-            if (q === -1 && r === 0)
-                return 1;
-        }
-        let number = 3 * ring * (ring - 1) + 2;
-        if (y > x) {
-            if (y > -x)
-                number += 5 * ring - r - q;
-            else
-                number += 1 * ring - r;
-        }
-        else {
-            if (y > -x)
-                number += 3 * ring + r;
-            else if (y < -x - ring)
-                number += 7 * ring + r + q;
-            else
-                number += 9 * ring + r + q;
-        }
-        return number;
+        this.hoveredHex = { row, col };
+        const name = this.cells.get(`${col},${row}`);
+        this.canvas.style.cursor =
+            name && this.canUnlock(name) ? "pointer" : "default";
+        this.redraw();
     }
     // Helper function to display hexagon number inside the hexagon
     displayHexNumber(centerX, centerY, number) {
@@ -229,28 +322,6 @@ class HexagonCanvas {
         this.ctx.textBaseline = "middle";
         this.ctx.fillText(number.toString(), centerX, centerY);
     }
-    // Helper function to redraw the hexagon with its original color
-    redrawHexagon(row, col, hexSize) {
-        // Calculate hexagon height and width
-        const hexHeight = Math.sqrt(3) * hexSize;
-        const hexWidth = 2 * hexSize;
-        // Define horizontal and vertical spacing between hexagons
-        const horizontalSpacing = hexWidth * 0.75; // 75% of hexWidth for horizontal spacing
-        const verticalSpacing = hexHeight; // Full height for vertical spacing
-        // Calculate center X and Y positions based on row and column
-        const centerX = this.canvas.width / 2 + this.offsetX + col * horizontalSpacing;
-        const centerY = this.canvas.height / 2 +
-            this.offsetY +
-            row * verticalSpacing +
-            (col % 2 === 1 ? verticalSpacing / 2 : 0); // Offset Y position for odd columns
-        // Set the fill color based on row and column
-        const originalFillStyle = (row + col) % 2 === 0 ? "#333" : "#61dafb";
-        // Draw the hexagon with curved corners (corner radius of 12)
-        this.drawHexagon(centerX, centerY, hexSize, 12, originalFillStyle);
-        // Optionally, display the hexagon number after redrawing
-        const hexNumber = this.calculateHexNumber(col, row);
-        this.displayHexNumber(centerX, centerY, hexNumber);
-    }
     clear() {
         this.ctx.clearRect(0, 0, this.canvas.width, this.canvas.height);
     }
@@ -30,6 +30,8 @@ export interface HTMXAfterRequestEvent extends CustomEvent {
 export interface HTMXBeforeSwapEvent extends CustomEvent {
     detail: HTMXEventDetail & {
         xhr: XMLHttpRequest;
+        shouldSwap: boolean;  // Whether htmx will swap the response in
+        isError: boolean;     // Whether htmx treats the response as an error
         pathInfo: {
             requestPath: string;
             finalRequestPath: string;
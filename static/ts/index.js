@@ -1,6 +1,7 @@
 "use strict";
 Object.defineProperty(exports, "__esModule", { value: true });
 const hexGrid_1 = require("./hexGrid");
+let chatEvents;
 window.alexdunmow = {
     get: function (id) {
         const el = document.getElementById(id);
@@ -10,47 +11,48 @@ window.alexdunmow = {
         return el;
     },
     initHTMX: function () {
+        // htmx doesn't swap 4xx/5xx responses. The server's error pages retarget
+        // themselves at #main-content, so let those through.
         document.body.addEventListener("htmx:beforeSwap", (event) => {
-            const path = event.detail.pathInfo.finalRequestPath;
-            const links = document.getElementsByClassName("active-link");
-            if (links) {
-                Array.from(links).forEach((link) => {
-                    link.classList.remove("active-link");
-                });
+            const xhr = event.detail.xhr;
+            if (xhr.status >= 400 && xhr.getResponseHeader("HX-Retarget")) {
+                event.detail.shouldSwap = true;
+                event.detail.isError = false;
             }
-            const activeLink = document.querySelector(`a.sidebar-link[href="${path}"]`);
-            if (activeLink) {
-                activeLink.classList.add("active-link");
+        });
+        // Boosted navigations keep the <body> element and <head>, so responses
+        // that change the theme announce it, along with the stylesheet that
+        // defines it, for the page to follow.
+        document.body.addEventListener("theme-changed", (event) => {
+            const themes = alexdunmow.get("themes-css");
+            if (themes.getAttribute("href") !== event.detail.stylesheet) {
+                themes.setAttribute("href", event.detail.stylesheet);
+            }
+            const classes = Array.from(document.body.classList);
+            for (const c of classes) {
+                if (c.startsWith("theme-")) {
+                    document.body.classList.remove(c);
+                }
+            }
+            if (event.detail.theme) {
+                document.body.classList.add("theme-" + event.detail.theme);
             }
         });
     },
     init: function () {
         console.log("hey, welcome to my website!");
     },
-    initSidebar: function () {
-        const themeSwitcher = document.getElementById("theme-switcher");
-        if (!themeSwitcher)
-            return;
-        themeSwitcher.addEventListener("click", function () {
-            document.body.classList.toggle("dark");
-        });
-    },
     initChatSidebar: function () {
-        // Initialize chat sidebar behavior
-        const chatInput = alexdunmow.get("chat-input");
-        const sendMessageButton = alexdunmow.get("send-message");
-        sendMessageButton.addEventListener("click", function () {
-            const message = chatInput.value;
-            if (message) {
-                const chatMessagesContainer = alexdunmow.get("chat-messages");
-                chatMessagesContainer.innerHTML += `
-            <div class="bg-accent p-2 rounded-lg shadow self-end text-primary">
-                <p class="text-sm"><strong>You:</strong> ${message}</p>
-            </div>
-        `;
-                chatInput.value = ""; // Clear input
-                chatMessagesContainer.scrollTop = chatMessagesContainer.scrollHeight; // Scroll to bottom
-            }
+        const chatMessagesContainer = alexdunmow.get("chat-messages");
+        // Boosted navigations re-render the sidebar, so close the stream left
+        // over from the previous one before opening a new one.
+        if (chatEvents) {
+            chatEvents.close();
+        }
+        chatEvents = new EventSource("/chat/events");
+        chatEvents.addEventListener("message", (event) => {
+            chatMessagesContainer.insertAdjacentHTML("beforeend", event.data);
+            chatMessagesContainer.scrollTop = chatMessagesContainer.scrollHeight; // Scroll to bottom
         });
     },
     initSkillTree: function (canvasElement) {
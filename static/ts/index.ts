@@ -10,11 +10,12 @@ import { HexagonCanvas } from "./hexGrid";
 export interface AlexDunmow {
   init: () => void;
   initHTMX: () => void;
-  initSidebar: () => void;
   initChatSidebar: () => void;
   initSkillTree: (canvasElement: HTMLCanvasElement) => void;
   get<T = HTMLDivElement>(id: string): T;
 }
+let chatEvents: EventSource | undefined;
+
 window.alexdunmow = {
   get: function <T>(id: string): T {
     const el = document.getElementById(id);
@@ -24,22 +25,36 @@ window.alexdunmow = {
     return el as unknown as T;
   },
   initHTMX: function () {
+    // htmx doesn't swap 4xx/5xx responses. The server's error pages retarget
+    // themselves at #main-content, so let those through.
     document.body.addEventListener(
       "htmx:beforeSwap",
       (event: HTMXBeforeSwapEvent) => {
-        const path = event.detail.pathInfo.finalRequestPath;
-        const links = document.getElementsByClassName("active-link");
-
-        if (links) {
-          Array.from(links).forEach((link) => {
-            link.classList.remove("active-link");
-          });
+        const xhr = event.detail.xhr;
+        if (xhr.status >= 400 && xhr.getResponseHeader("HX-Retarget")) {
+          event.detail.shouldSwap = true;
+          event.detail.isError = false;
+        }
+      },
+    );
+    // Boosted navigations keep the <body> element and <head>, so responses
+    // that change the theme announce it, along with the stylesheet that
+    // defines it, for the page to follow.
+    document.body.addEventListener(
+      "theme-changed",
+      (event: CustomEvent<{ theme: string; stylesheet: string }>) => {
+        const themes = alexdunmow.get<HTMLLinkElement>("themes-css");
+        if (themes.getAttribute("href") !== event.detail.stylesheet) {
+          themes.setAttribute("href", event.detail.stylesheet);
         }
-        const activeLink = document.querySelector(
-          `a.sidebar-link[href="${path}"]`,
-        );
-        if (activeLink) {
-          activeLink.classList.add("active-link");
+        const classes = Array.from(document.body.classList);
+        for (const c of classes) {
+          if (c.startsWith("theme-")) {
+            document.body.classList.remove(c);
+          }
+        }
+        if (event.detail.theme) {
+          document.body.classList.add("theme-" + event.detail.theme);
         }
       },
     );
@@ -47,32 +62,18 @@ window.alexdunmow = {
   init: function () {
     console.log("hey, welcome to my website!");
   },
-  initSidebar: function () {
-    const themeSwitcher = document.getElementById("theme-switcher");
-    if (!themeSwitcher) return;
-
-    themeSwitcher.addEventListener("click", function () {
-      document.body.classList.toggle("dark");
-    });
-  },
   initChatSidebar: function () {
-    // Initialize chat sidebar behavior
-    const chatInput = alexdunmow.get<HTMLInputElement>("chat-input");
-    const sendMessageButton = alexdunmow.get<HTMLButtonElement>("send-message");
-
-    sendMessageButton.addEventListener("click", function () {
-      const message = chatInput.value;
+    const chatMessagesContainer = alexdunmow.get("chat-messages");
 
-      if (message) {
-        const chatMessagesContainer = alexdunmow.get("chat-messages");
-        chatMessagesContainer.innerHTML += `
-            <div class="bg-accent p-2 rounded-lg shadow self-end text-primary">
-                <p class="text-sm"><strong>You:</strong> ${message}</p>
-            </div>
-        `;
-        chatInput.value = ""; // Clear input
-        chatMessagesContainer.scrollTop = chatMessagesContainer.scrollHeight; // Scroll to bottom
-      }
+    // Boosted navigations re-render the sidebar, so close the stream left
+    // over from the previous one before opening a new one.
+    if (chatEvents) {
+      chatEvents.close();
+    }
+    chatEvents = new EventSource("/chat/events");
+    chatEvents.addEventListener("message", (event: MessageEvent<string>) => {
+      chatMessagesContainer.insertAdjacentHTML("beforeend", event.data);
+      chatMessagesContainer.scrollTop = chatMessagesContainer.scrollHeight; // Scroll to bottom
     });
   },
   initSkillTree: function (canvasElement: HTMLCanvasElement) {